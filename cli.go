@@ -0,0 +1,443 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/Utkarsh736/linkscout/crawler"
+)
+
+// Exit codes
+const (
+	exitOK    = 0
+	exitError = 1 // The command ran but failed
+	exitUsage = 2 // Bad flags, arguments or environment variables
+)
+
+// envPrefix starts the environment variable behind every flag, e.g.
+// LINKSCOUT_MAX_PAGES for --max-pages
+const envPrefix = "LINKSCOUT_"
+
+const usageText = `LinkScout crawls a website and writes SEO, link and content reports.
+
+Usage:
+  linkscout <command> [flags] [arguments]
+  linkscout <URL> <maxConcurrency> <maxPages>   (same as crawl)
+
+Commands:
+  crawl    Crawl a site and write every report, plus crawl.json
+  report   Regenerate the reports from a saved crawl.json
+  diff     Compare two crawl.json files
+  serve    Serve a report directory over HTTP
+  config   Print the effective configuration ("config print")
+  help     Show help for a command
+
+Every flag can also be set through an environment variable named after it,
+e.g. LINKSCOUT_MAX_PAGES for --max-pages. Settings can also come from a YAML
+or TOML profile given with --config. Flags win over the environment, which
+wins over the profile.
+
+Run "linkscout help <command>" for the flags of a command.
+`
+
+// commands maps each subcommand name onto its entry point
+var commands = map[string]func(args []string, stdout, stderr io.Writer) int{
+	"crawl":  runCrawlCommand,
+	"report": runReportCommand,
+	"diff":   runDiffCommand,
+	"serve":  runServeCommand,
+	"config": runConfigCommand,
+}
+
+// run dispatches a command line and returns the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usageText)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		if len(args) > 1 && args[0] == "help" {
+			if command, ok := commands[args[1]]; ok {
+				return command([]string{"--help"}, stdout, stderr)
+			}
+			fmt.Fprintf(stderr, "error: unknown command %q\n", args[1])
+			return exitUsage
+		}
+		fmt.Fprint(stdout, usageText)
+		return exitOK
+	}
+
+	if command, ok := commands[args[0]]; ok {
+		return command(args[1:], stdout, stderr)
+	}
+
+	// Anything else is the legacy "<URL> <maxConcurrency> <maxPages>" form,
+	// or crawl flags without the subcommand name
+	if strings.Contains(args[0], "://") || strings.HasPrefix(args[0], "-") {
+		return runCrawlCommand(args, stdout, stderr)
+	}
+
+	fmt.Fprintf(stderr, "error: unknown command %q\n\n%s", args[0], usageText)
+	return exitUsage
+}
+
+// newFlagSet creates a flag set that reports errors instead of exiting.
+// summary is printed above the flag list by --help.
+func newFlagSet(name, summary string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "%s\nFlags:\n", summary)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nEvery flag falls back to %s<FLAG_NAME> when not given.\n", envPrefix)
+	}
+	return fs
+}
+
+// parseFlags parses flags anywhere among the arguments, so
+// "crawl https://example.com --max-pages 10" works, then fills every flag
+// not given on the command line from its environment variable. It returns
+// the positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	return positional, applyEnv(fs)
+}
+
+// parseArgs parses flags anywhere among the arguments and returns the
+// positional ones
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, flagParseError{err}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	return positional, nil
+}
+
+// applyEnv fills every flag that hasn't been set yet from its environment variable
+func applyEnv(fs *flag.FlagSet) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	var envErr error
+	fs.VisitAll(func(f *flag.Flag) {
+		if set[f.Name] || envErr != nil {
+			return
+		}
+		name := envVarName(f.Name)
+		if value, ok := os.LookupEnv(name); ok {
+			if err := fs.Set(f.Name, value); err != nil {
+				envErr = fmt.Errorf("invalid value %q for %s: %w", value, name, err)
+			}
+		}
+	})
+
+	return envErr
+}
+
+// flagParseError is an error the flag package has already printed, along with the usage
+type flagParseError struct{ err error }
+
+func (e flagParseError) Error() string { return e.err.Error() }
+func (e flagParseError) Unwrap() error { return e.err }
+
+// envVarName returns the environment variable behind a flag
+func envVarName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// usageFailure prints a validation error to stderr and returns the usage
+// exit code. --help is not a failure.
+func usageFailure(err error, stderr io.Writer) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if !errors.As(err, &flagParseError{}) {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+	}
+	return exitUsage
+}
+
+// csvFlags are the report layout flags shared by crawl and report
+type csvFlags struct {
+	columns        string
+	delimiter      string
+	multiSeparator string
+	longFormat     bool
+	bom            bool
+	sortBy         string
+}
+
+// register adds the report layout flags to a flag set
+func (f *csvFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.columns, "columns", "", "comma-separated report.csv columns (default: the standard set)")
+	fs.StringVar(&f.delimiter, "delimiter", ",", `CSV field delimiter, e.g. ";" or "\t" for TSV`)
+	fs.StringVar(&f.multiSeparator, "multi-separator", ";", "separator for multi-valued fields")
+	fs.BoolVar(&f.longFormat, "long-format", false, "write one report.csv row per outgoing link")
+	fs.BoolVar(&f.bom, "bom", false, "start CSV files with a UTF-8 byte order mark for Excel")
+	fs.StringVar(&f.sortBy, "sort", string(crawler.DefaultSortKey), "report.csv row order: url, depth, inbound or status")
+}
+
+// options validates the flags and converts them into CSVOptions. fields
+// are the custom fields that may be used as columns.
+func (f *csvFlags) options(fields []string) (crawler.CSVOptions, error) {
+	opts := crawler.CSVOptions{
+		Fields:         fields,
+		MultiSeparator: f.multiSeparator,
+		LongFormat:     f.longFormat,
+		BOM:            f.bom,
+	}
+
+	if f.columns != "" {
+		for _, name := range strings.Split(f.columns, ",") {
+			opts.Columns = append(opts.Columns, strings.TrimSpace(name))
+		}
+		if err := crawler.ValidateColumns(opts.Columns, fields); err != nil {
+			return crawler.CSVOptions{}, err
+		}
+	}
+
+	delimiter := f.delimiter
+	if delimiter == `\t` {
+		delimiter = "\t"
+	}
+	if utf8.RuneCountInString(delimiter) != 1 {
+		return crawler.CSVOptions{}, fmt.Errorf("delimiter must be a single character, got %q", f.delimiter)
+	}
+	opts.Delimiter, _ = utf8.DecodeRuneInString(delimiter)
+	if !crawler.ValidCSVDelimiter(opts.Delimiter) {
+		return crawler.CSVOptions{}, fmt.Errorf("invalid CSV delimiter %q", f.delimiter)
+	}
+
+	sortBy, err := crawler.ParseSortKey(f.sortBy)
+	if err != nil {
+		return crawler.CSVOptions{}, err
+	}
+	opts.SortBy = sortBy
+
+	return opts, nil
+}
+
+// analysisFlags are the report analysis flags shared by crawl and report
+type analysisFlags struct {
+	collapseQuery bool
+	selfLoops     bool
+	damping       float64
+	iterations    int
+	tolerance     float64
+	nofollow      string
+	disableRules  string
+	ruleSeverity  keyValueFlag
+	thresholds    crawler.RuleThresholds
+	duplicates    float64
+	maxImageBytes int64
+}
+
+// register adds the analysis flags to a flag set
+func (f *analysisFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&f.collapseQuery, "collapse-query", false, "merge URLs that differ only in their query string into one link graph node")
+	fs.BoolVar(&f.selfLoops, "self-loops", false, "keep links from a page to itself in the link graph")
+	fs.Float64Var(&f.damping, "damping", 0.85, "PageRank damping factor: the probability of following a link")
+	fs.IntVar(&f.iterations, "pagerank-iterations", 100, "upper bound on PageRank and HITS iterations")
+	fs.Float64Var(&f.tolerance, "pagerank-tolerance", 1e-6, "stop iterating once the total score change drops below this")
+	fs.StringVar(&f.nofollow, "nofollow", string(crawler.NofollowIgnore), "nofollow links in the link scores: ignore, evaporate or follow")
+
+	defaults := crawler.RuleThresholds{}.WithDefaults()
+	f.ruleSeverity = keyValueFlag{}
+	fs.StringVar(&f.disableRules, "disable-rules", "", "comma-separated SEO rule IDs to leave out of issues.csv")
+	fs.Var(f.ruleSeverity, "rule-severity", "SEO rule severity override as ID=error|warning|info (repeatable)")
+	fs.IntVar(&f.thresholds.TitleMinLength, "title-min-length", defaults.TitleMinLength, "flag titles shorter than this many characters")
+	fs.IntVar(&f.thresholds.TitleMaxLength, "title-max-length", defaults.TitleMaxLength, "flag titles longer than this many characters")
+	fs.IntVar(&f.thresholds.DescriptionMinLength, "description-min-length", defaults.DescriptionMinLength, "flag meta descriptions shorter than this many characters")
+	fs.IntVar(&f.thresholds.DescriptionMaxLength, "description-max-length", defaults.DescriptionMaxLength, "flag meta descriptions longer than this many characters")
+	fs.Float64Var(&f.duplicates, "duplicate-threshold", 0.9, "minimum SimHash similarity for near-duplicate pages, from 0 to 1")
+	fs.Int64Var(&f.maxImageBytes, "max-image-bytes", crawler.DefaultMaxImageBytes, "flag probed images larger than this many bytes as oversized")
+}
+
+// apply validates the flags and sets them on the report options. Rule
+// severities from the profile are merged under the flag's.
+func (f *analysisFlags) apply(opts *crawler.ReportOptions, profile crawlProfile) error {
+	opts.CollapseQuery = f.collapseQuery
+	opts.KeepSelfLoops = f.selfLoops
+
+	nofollow, err := crawler.ParseNofollowMode(f.nofollow)
+	if err != nil {
+		return err
+	}
+	opts.PageRank = crawler.PageRankOptions{Damping: f.damping, Tolerance: f.tolerance, MaxIterations: f.iterations, Nofollow: nofollow}
+	if err := opts.PageRank.Validate(); err != nil {
+		return err // Checked before the defaults, which would replace a zero damping factor
+	}
+	opts.DuplicateThreshold = f.duplicates
+	opts.MaxImageBytes = f.maxImageBytes
+
+	opts.Rules = crawler.RulesOptions{
+		Thresholds: f.thresholds,
+		Severities: mergeValues(profile.Rules.Severity, f.ruleSeverity),
+	}
+	if f.disableRules != "" {
+		for _, id := range strings.Split(f.disableRules, ",") {
+			opts.Rules.Disabled = append(opts.Rules.Disabled, strings.TrimSpace(id))
+		}
+	}
+	return opts.Validate()
+}
+
+// fetchFlags are the request flags shared by crawl and report, which
+// sends them with its image probes
+type fetchFlags struct {
+	timeout    time.Duration
+	rateLimit  float64
+	userAgent  string
+	headers    headerFlag
+	cookieFile string
+	auth       authFlag
+}
+
+// register adds the request flags to a flag set
+func (f *fetchFlags) register(fs *flag.FlagSet) {
+	f.headers = headerFlag{}
+	fs.DurationVar(&f.timeout, "timeout", 30*time.Second, "give up on a request after this long (0 waits forever)")
+	fs.Float64Var(&f.rateLimit, "rate-limit", 0, "maximum requests per second across all workers (0 is unlimited)")
+	fs.StringVar(&f.userAgent, "user-agent", crawler.DefaultUserAgent, "User-Agent header sent with every request")
+	fs.Var(f.headers, "header", `extra request header as "Name: value" (repeatable)`)
+	fs.StringVar(&f.cookieFile, "cookies-file", "", "Netscape cookies.txt file to start the cookie jar with")
+	fs.Var(&f.auth, "auth", "per-host credentials as HOST=basic|bearer:env:VAR or HOST=basic|bearer:file:PATH (repeatable)")
+}
+
+// apply validates the flags and sets them on the fetch options. Headers
+// and credentials from flags come first, then the profile's.
+func (f *fetchFlags) apply(opts *crawler.FetchOptions, profile crawlProfile) error {
+	if f.timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	if f.rateLimit < 0 {
+		return fmt.Errorf("rate limit must not be negative")
+	}
+	opts.Timeout = f.timeout
+	opts.RateLimit = f.rateLimit
+	opts.UserAgent = f.userAgent
+	opts.CookieFile = f.cookieFile
+
+	opts.Headers = nil
+	if len(f.headers) > 0 || len(profile.Fetch.Headers) > 0 {
+		opts.Headers = make(map[string]string)
+		for name, value := range profile.Fetch.Headers {
+			opts.Headers[http.CanonicalHeaderKey(name)] = value
+		}
+		for name, value := range f.headers {
+			opts.Headers[name] = value
+		}
+	}
+	opts.Auth = f.auth
+	for _, entry := range profile.Fetch.Auth {
+		spec, _ := entry.spec() // Checked when the profile was loaded
+		opts.Auth = append(opts.Auth, spec)
+	}
+	return nil
+}
+
+// headerFlag collects repeated --header "Name: value" flags
+type headerFlag map[string]string
+
+func (h headerFlag) String() string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func (h headerFlag) Set(raw string) error {
+	name, value, ok := strings.Cut(raw, ":")
+	name = strings.TrimSpace(name)
+	if !ok || !httpguts.ValidHeaderFieldName(name) {
+		return fmt.Errorf(`expected "Name: value", got %q`, name)
+	}
+	h[http.CanonicalHeaderKey(name)] = strings.TrimSpace(value)
+	return nil
+}
+
+// authFlag collects repeated --auth HOST=TYPE:env:VAR flags
+type authFlag []crawler.AuthSpec
+
+func (a *authFlag) String() string {
+	specs := make([]string, len(*a))
+	for i, spec := range *a {
+		specs[i] = spec.String()
+	}
+	return strings.Join(specs, ", ")
+}
+
+func (a *authFlag) Set(raw string) error {
+	spec, err := crawler.ParseAuthSpec(raw)
+	if err != nil {
+		return err
+	}
+	*a = append(*a, spec)
+	return nil
+}
+
+// patternFlag collects repeated --include and --exclude regular
+// expressions. A value may hold several patterns separated by spaces,
+// which never appear in a URL, so regex commas need no escaping.
+type patternFlag []string
+
+func (p *patternFlag) String() string {
+	return strings.Join(*p, " ")
+}
+
+func (p *patternFlag) Set(raw string) error {
+	for _, pattern := range strings.Fields(raw) {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return err
+		}
+		*p = append(*p, pattern)
+	}
+	return nil
+}
+
+// keyValueFlag collects repeated name=value flags
+type keyValueFlag map[string]string
+
+func (kv keyValueFlag) String() string {
+	names := make([]string, 0, len(kv))
+	for name := range kv {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func (kv keyValueFlag) Set(raw string) error {
+	name, value, ok := strings.Cut(raw, "=")
+	if !ok || name == "" {
+		return fmt.Errorf(`expected "name=value", got %q`, name)
+	}
+	kv[name] = value
+	return nil
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Utkarsh736/linkscout/crawler"
+)
+
+func TestParseCrawlArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		env         map[string]string
+		url         string
+		concurrency int
+		maxPages    int
+		robots      crawler.RobotsPolicy
+	}{
+		{
+			name:        "legacy positional form",
+			args:        []string{"https://example.com", "3", "25"},
+			url:         "https://example.com",
+			concurrency: 3,
+			maxPages:    25,
+			robots:      crawler.RobotsIgnore, // As before robots directives were supported
+		},
+		{
+			name:        "legacy form with a robots policy",
+			args:        []string{"--robots", "respect", "https://example.com", "3", "25"},
+			url:         "https://example.com",
+			concurrency: 3,
+			maxPages:    25,
+			robots:      crawler.RobotsRespect,
+		},
+		{
+			name:        "legacy form with a robots policy from the environment",
+			args:        []string{"https://example.com", "3", "25"},
+			env:         map[string]string{"LINKSCOUT_ROBOTS": "respect"},
+			url:         "https://example.com",
+			concurrency: 3,
+			maxPages:    25,
+			robots:      crawler.RobotsRespect,
+		},
+		{
+			name:        "flags with defaults",
+			args:        []string{"--url", "https://example.com", "--max-pages", "10"},
+			url:         "https://example.com",
+			concurrency: 5,
+			maxPages:    10,
+			robots:      crawler.RobotsRespect,
+		},
+		{
+			name:        "flags after the URL",
+			args:        []string{"https://example.com", "--concurrency=2"},
+			url:         "https://example.com",
+			concurrency: 2,
+			maxPages:    100,
+			robots:      crawler.RobotsRespect,
+		},
+		{
+			name:        "environment fallback",
+			args:        []string{"--concurrency", "4"},
+			env:         map[string]string{"LINKSCOUT_URL": "https://env.example.com", "LINKSCOUT_CONCURRENCY": "9", "LINKSCOUT_MAX_PAGES": "7"},
+			url:         "https://env.example.com",
+			concurrency: 4, // Flags win over the environment
+			maxPages:    7,
+			robots:      crawler.RobotsRespect,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+
+			var stderr bytes.Buffer
+			opts, err := parseCrawlArgs(tc.args, &stderr)
+			if err != nil {
+				t.Fatalf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+			}
+			if opts.URL != tc.url || opts.MaxConcurrency != tc.concurrency || opts.MaxPages != tc.maxPages {
+				t.Errorf("Test %v - %s FAIL: expected %s/%d/%d, got %s/%d/%d", i, tc.name,
+					tc.url, tc.concurrency, tc.maxPages, opts.URL, opts.MaxConcurrency, opts.MaxPages)
+			}
+			if opts.Reports.Robots != tc.robots || opts.Reports.OutputDir != "." {
+				t.Errorf("Test %v - %s FAIL: unexpected report options %+v", i, tc.name, opts.Reports)
+			}
+		})
+	}
+}
+
+func TestParseAnalysisFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected func(opts crawler.ReportOptions) bool
+	}{
+		{
+			name:     "defaults",
+			args:     nil,
+			expected: func(opts crawler.ReportOptions) bool { return !opts.CollapseQuery && !opts.KeepSelfLoops },
+		},
+		{
+			name:     "link graph shape",
+			args:     []string{"--collapse-query", "--self-loops"},
+			expected: func(opts crawler.ReportOptions) bool { return opts.CollapseQuery && opts.KeepSelfLoops },
+		},
+		{
+			name: "link scores",
+			args: []string{"--damping", "0.7", "--pagerank-iterations", "50", "--pagerank-tolerance", "0.001", "--nofollow", "evaporate"},
+			expected: func(opts crawler.ReportOptions) bool {
+				return opts.PageRank == crawler.PageRankOptions{Damping: 0.7, MaxIterations: 50, Tolerance: 0.001, Nofollow: crawler.NofollowEvaporate}
+			},
+		},
+		{
+			name:     "duplicate threshold",
+			args:     []string{"--duplicate-threshold", "0.8"},
+			expected: func(opts crawler.ReportOptions) bool { return opts.DuplicateThreshold == 0.8 },
+		},
+		{
+			name:     "image size limit",
+			args:     []string{"--max-image-bytes", "500000"},
+			expected: func(opts crawler.ReportOptions) bool { return opts.MaxImageBytes == 500000 },
+		},
+		{
+			name: "SEO rules",
+			args: []string{"--disable-rules", "h1-missing, img-alt-missing", "--rule-severity", "title-duplicate=error", "--title-max-length", "70"},
+			expected: func(opts crawler.ReportOptions) bool {
+				rules := opts.Rules
+				return len(rules.Disabled) == 2 && rules.Disabled[1] == "img-alt-missing" &&
+					rules.Severities["title-duplicate"] == "error" &&
+					rules.Thresholds.TitleMaxLength == 70 && rules.Thresholds.TitleMinLength == 30
+			},
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			opts, err := parseCrawlArgs(append([]string{"https://example.com"}, tc.args...), &stderr)
+			if err != nil {
+				t.Fatalf("Test %v - %s FAIL: unexpected error: %v", i, tc.name, err)
+			}
+			if !tc.expected(opts.Reports) {
+				t.Errorf("Test %v - %s FAIL: unexpected report options %+v", i, tc.name, opts.Reports)
+			}
+		})
+	}
+}
+
+func TestRunValidationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		message string
+	}{
+		{name: "no arguments", args: nil, message: "Usage:"},
+		{name: "unknown command", args: []string{"crawlz"}, message: `unknown command "crawlz"`},
+		{name: "too many arguments", args: []string{"https://example.com", "1", "2", "3"}, message: "got 4 arguments"},
+		{name: "bad concurrency", args: []string{"https://example.com", "zero", "5"}, message: "couldn't parse maxConcurrency"},
+		{name: "concurrency below one", args: []string{"https://example.com", "0", "5"}, message: "maxConcurrency must be at least 1"},
+		{name: "missing URL", args: []string{"crawl"}, message: "no URL given"},
+		{name: "relative URL", args: []string{"crawl", "example.com"}, message: "must be absolute"},
+		{name: "unknown flag", args: []string{"crawl", "--nope"}, message: "flag provided but not defined"},
+		{name: "bad robots policy", args: []string{"crawl", "https://example.com", "--robots", "maybe"}, message: "unknown robots policy"},
+		{name: "bad column", args: []string{"crawl", "https://example.com", "--columns", "page_url,nope"}, message: `unknown column "nope"`},
+		{name: "bad environment value", args: []string{"crawl", "https://example.com"}, env: map[string]string{"LINKSCOUT_MAX_PAGES": "lots"}, message: "LINKSCOUT_MAX_PAGES"},
+		{name: "bad extract selector", args: []string{"crawl", "https://example.com", "--extract", "price=span["}, message: "invalid selector for price"},
+		{name: "unknown custom column", args: []string{"crawl", "https://example.com", "--extract", "price=.price", "--columns", "page_url,sku"}, message: `unknown column "sku"`},
+		{name: "streamed inbound sort", args: []string{"crawl", "https://example.com", "--stream", "--sort", "inbound"}, message: "--stream can't sort by inbound links"},
+		{name: "bad damping factor", args: []string{"crawl", "https://example.com", "--damping", "1.5"}, message: "damping factor must be between 0 and 1"},
+		{name: "zero damping factor", args: []string{"report", "--damping", "0"}, message: "damping factor must be between 0 and 1, exclusive, got 0"},
+		{name: "bad nofollow mode", args: []string{"report", "--nofollow", "maybe"}, message: "unknown nofollow mode"},
+		{name: "bad duplicate threshold", args: []string{"crawl", "https://example.com", "--duplicate-threshold", "1.2"}, message: "duplicate threshold must be between 0 and 1"},
+		{name: "negative image size limit", args: []string{"report", "--max-image-bytes", "-1"}, message: "image size limit must not be negative"},
+		{name: "unknown SEO rule", args: []string{"crawl", "https://example.com", "--disable-rules", "nope"}, message: `unknown SEO rule "nope"`},
+		{name: "bad rule severity", args: []string{"report", "--rule-severity", "title-duplicate=fatal"}, message: "rule title-duplicate"},
+		{name: "negative probe rate limit", args: []string{"report", "--probe-images", "--rate-limit", "-1"}, message: "rate limit must not be negative"},
+		{name: "record and replay", args: []string{"crawl", "https://example.com", "--record", "a.json", "--replay", "b.json"}, message: "can't be used together"},
+		{name: "diff needs two files", args: []string{"diff", "a.json"}, message: "expected <old crawl.json> <new crawl.json>"},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+
+			var stdout, stderr bytes.Buffer
+			code := run(tc.args, &stdout, &stderr)
+			if code == exitOK {
+				t.Errorf("Test %v - %s FAIL: expected a non-zero exit code", i, tc.name)
+			}
+			if !strings.Contains(stderr.String(), tc.message) {
+				t.Errorf("Test %v - %s FAIL: expected stderr to contain %q, got %q", i, tc.name, tc.message, stderr.String())
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("Test %v - %s FAIL: expected nothing on stdout, got %q", i, tc.name, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunHelp(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--help"}, &stdout, &stderr); code != exitOK {
+		t.Errorf("expected exit code 0, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "Commands:") {
+		t.Errorf("expected usage on stdout, got %q", stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"help", "crawl"}, &stdout, &stderr); code != exitOK {
+		t.Errorf("expected exit code 0, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "-max-pages") {
+		t.Errorf("expected crawl flags in help, got %q", stderr.String())
+	}
+}
+
+func TestCrawlReportDiffCommands(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sitemap.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><title>Page %s</title></head><body><h1>%s</h1><a href="/a">A</a><a href="/b">B</a></body></html>`, r.URL.Path, r.URL.Path)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	crawlDir := filepath.Join(dir, "crawl")
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"crawl", server.URL, "--output-dir", crawlDir, "--max-pages", "5", "--probe-images=false"}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("crawl failed with exit code %d: %s", code, stderr.String())
+	}
+	// Progress goes to stderr, leaving stdout for the summary
+	if strings.Contains(stdout.String(), "Crawling:") || !strings.Contains(stderr.String(), "Crawling: "+server.URL) {
+		t.Errorf("expected progress on stderr only, got stdout %q and stderr %q", stdout.String(), stderr.String())
+	}
+	for _, name := range []string{"report.csv", "edges.csv", "issues.csv", "security.csv", "linkgraph.dot", crawler.SnapshotFilename} {
+		if _, err := os.Stat(filepath.Join(crawlDir, name)); err != nil {
+			t.Errorf("expected %s to be written: %v", name, err)
+		}
+	}
+
+	// Regenerating the reports from the snapshot gives the same page report
+	reportDir := filepath.Join(dir, "report")
+	snapshotPath := filepath.Join(crawlDir, crawler.SnapshotFilename)
+	code = run([]string{"report", snapshotPath, "--output-dir", reportDir}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("report failed with exit code %d: %s", code, stderr.String())
+	}
+	crawled, _ := os.ReadFile(filepath.Join(crawlDir, "report.csv"))
+	regenerated, _ := os.ReadFile(filepath.Join(reportDir, "report.csv"))
+	if len(crawled) == 0 || !bytes.Equal(crawled, regenerated) {
+		t.Errorf("expected identical reports, got:\n%s\nvs\n%s", crawled, regenerated)
+	}
+
+	// A crawl compared with itself has no changes
+	stdout.Reset()
+	diffPath := filepath.Join(dir, "diff.csv")
+	code = run([]string{"diff", "--output", diffPath, snapshotPath, snapshotPath}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("diff failed with exit code %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "0 pages added, 0 removed, 0 changed") {
+		t.Errorf("unexpected diff summary: %q", stdout.String())
+	}
+}
+
+func TestCrawlStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/c">C</a><a href="/b">B</a><a href="/a">A</a></body></html>`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"crawl", server.URL, "--output-dir", dir, "--stream", "--columns", "page_url,depth"}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("crawl failed with exit code %d: %s", code, stderr.String())
+	}
+
+	// The streamed report is sorted, and nothing needing every page is written
+	report, _ := os.ReadFile(filepath.Join(dir, "report.csv"))
+	expected := "page_url,depth\n" + server.URL + ",0\n" + server.URL + "/a,1\n" + server.URL + "/b,1\n" + server.URL + "/c,1\n"
+	if string(report) != expected {
+		t.Errorf("expected a sorted report:\n%s\ngot:\n%s", expected, report)
+	}
+	for _, name := range []string{"edges.csv", crawler.SnapshotFilename} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("expected %s to be skipped", name)
+		}
+	}
+}
+
+func TestCrawlRecordReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/logo.png" {
+			w.Header().Set("Content-Type", "image/png")
+			w.Header().Set("Content-Length", "300000")
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><title>Page %s</title></head><body><a href="/a">A</a><img src="/logo.png"></body></html>`, r.URL.Path)
+	}))
+
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "archive.json")
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"crawl", server.URL, "--output-dir", filepath.Join(dir, "live"), "--record", archivePath}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("crawl failed with exit code %d: %s", code, stderr.String())
+	}
+
+	// The replayed crawl needs no server, image probes included
+	server.Close()
+	code = run([]string{"crawl", server.URL, "--output-dir", filepath.Join(dir, "replayed"), "--replay", archivePath}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("replay failed with exit code %d: %s", code, stderr.String())
+	}
+	for _, name := range []string{"report.csv", "images.csv"} {
+		live, _ := os.ReadFile(filepath.Join(dir, "live", name))
+		replayed, _ := os.ReadFile(filepath.Join(dir, "replayed", name))
+		if len(live) == 0 || !bytes.Equal(live, replayed) {
+			t.Errorf("expected identical %s, got:\n%s\nvs\n%s", name, live, replayed)
+		}
+	}
+	if images, _ := os.ReadFile(filepath.Join(dir, "replayed", "images.csv")); !strings.Contains(string(images), "oversized") {
+		t.Errorf("expected the replayed probe to flag the logo, got:\n%s", images)
+	}
+}
+
+func TestCrawlExtractFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><meta name="sku" content="GT-100"></head><body><span class="price">12.50</span></body></html>`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"crawl", server.URL, "--output-dir", dir, "--probe-images=false",
+		"--extract", "price=.price", "--extract", "sku=meta[name=sku]@content"}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("crawl failed with exit code %d: %s", code, stderr.String())
+	}
+	report, _ := os.ReadFile(filepath.Join(dir, "report.csv"))
+	if !strings.Contains(string(report), ",price,sku\n") || !strings.Contains(string(report), ",12.50,GT-100\n") {
+		t.Errorf("expected the custom fields in report.csv:\n%s", report)
+	}
+
+	// The report command finds the fields in the snapshot
+	reportDir := filepath.Join(dir, "report")
+	code = run([]string{"report", filepath.Join(dir, crawler.SnapshotFilename), "--output-dir", reportDir, "--columns", "page_url,sku"}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("report failed with exit code %d: %s", code, stderr.String())
+	}
+	report, _ = os.ReadFile(filepath.Join(reportDir, "report.csv"))
+	if !strings.HasSuffix(string(report), server.URL+",GT-100\n") {
+		t.Errorf("expected the sku column in the regenerated report:\n%s", report)
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	oldSnapshot := crawler.Snapshot{Pages: map[string]crawler.PageData{
+		"example.com":      {URL: "https://example.com", Title: "Home", StatusCode: 200},
+		"example.com/gone": {URL: "https://example.com/gone", StatusCode: 200},
+	}}
+	newSnapshot := crawler.Snapshot{Pages: map[string]crawler.PageData{
+		"example.com":     {URL: "https://example.com", Title: "Welcome", StatusCode: 301},
+		"example.com/new": {URL: "https://example.com/new", StatusCode: 200},
+	}}
+
+	expected := []crawler.PageChange{
+		{Change: crawler.ChangeChanged, URL: "https://example.com", Field: "status_code", Old: "200", New: "301"},
+		{Change: crawler.ChangeChanged, URL: "https://example.com", Field: "title", Old: "Home", New: "Welcome"},
+		{Change: crawler.ChangeRemoved, URL: "https://example.com/gone"},
+		{Change: crawler.ChangeAdded, URL: "https://example.com/new"},
+	}
+	actual, err := crawler.DiffSnapshots(oldSnapshot, newSnapshot)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fmt.Sprint(actual) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
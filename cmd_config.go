@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+const configSummary = `Show the effective crawl configuration: flag defaults, overridden by the
+--config profile, the environment and the command line, in that order.
+
+Usage:
+  linkscout config print [flags] [URL]
+`
+
+// runConfigCommand is the entry point of "linkscout config"
+func runConfigCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "print" {
+		if len(args) > 0 && (args[0] == "-h" || args[0] == "-help" || args[0] == "--help") {
+			fmt.Fprint(stderr, configSummary)
+			return exitOK
+		}
+		return usageFailure(fmt.Errorf("expected \"config print\""), stderr)
+	}
+
+	fs := newFlagSet("config print", configSummary, stderr)
+	var format string
+	fs.StringVar(&format, "format", string(profileYAML), "output format: yaml or toml")
+
+	opts, err := parseCrawlFlags(fs, args[1:])
+	if err != nil {
+		return usageFailure(err, stderr)
+	}
+	outputFormat, err := parseProfileFormat(format)
+	if err != nil {
+		return usageFailure(err, stderr)
+	}
+
+	// The output is itself a valid profile
+	if err := writeProfile(stdout, profileFromOptions(opts), outputFormat); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
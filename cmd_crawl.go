@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/Utkarsh736/linkscout/crawler"
+)
+
+// crawlOptions are the validated settings of the crawl command
+type crawlOptions struct {
+	URL            string
+	MaxConcurrency int
+	MaxPages       int
+	Include        []string // Only crawl links matching one of these patterns
+	Exclude        []string // Never crawl links matching any of these patterns
+	Fetch          crawler.FetchOptions
+	Normalize      crawler.NormalizeOptions
+	Reports        crawler.ReportOptions
+	Extract        map[string]string // Custom field name to CSS selector, see selectorExtractors
+	Stream         bool              // Only write report.csv, without keeping pages in memory
+	Record         string            // Archive every response to this file
+	Replay         string            // Crawl from this archive instead of the network
+}
+
+const crawlSummary = `Crawl a site and write every report, plus crawl.json for "report" and "diff".
+Every page is kept in memory for the site-wide reports; use --stream on
+sites too large for that.
+
+Usage:
+  linkscout crawl [flags] <URL>
+  linkscout crawl <URL> <maxConcurrency> <maxPages>
+`
+
+// runCrawlCommand is the entry point of "linkscout crawl"
+func runCrawlCommand(args []string, stdout, stderr io.Writer) int {
+	opts, err := parseCrawlArgs(args, stderr)
+	if err == nil && opts.URL == "" {
+		err = fmt.Errorf("no URL given (use --url, an argument, %s or a profile)", envVarName("url"))
+	}
+	if err != nil {
+		return usageFailure(err, stderr)
+	}
+
+	if err := runCrawl(opts, stdout, stderr); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// parseCrawlArgs reads and validates the crawl flags. The URL, concurrency
+// and page limit may also be given positionally, as in the original CLI.
+func parseCrawlArgs(args []string, stderr io.Writer) (crawlOptions, error) {
+	return parseCrawlFlags(newFlagSet("crawl", crawlSummary, stderr), args)
+}
+
+// parseCrawlFlags registers the crawl flags on fs, parses args and
+// validates the result. Settings come from, in order of precedence: the
+// command line, environment variables, the --config profile and the flag
+// defaults. The URL may be empty.
+func parseCrawlFlags(fs *flag.FlagSet, args []string) (crawlOptions, error) {
+	var opts crawlOptions
+	var configPath, robots string
+	var csv csvFlags
+	var analysis analysisFlags
+	var fetch fetchFlags
+	var include, exclude patternFlag
+	loginFields := keyValueFlag{}
+	loginSecrets := keyValueFlag{}
+	extract := keyValueFlag{}
+	fs.StringVar(&configPath, "config", "", "YAML or TOML crawl profile; flags override its settings")
+	fs.StringVar(&opts.URL, "url", "", "base URL to start crawling, including http:// or https://")
+	fs.IntVar(&opts.MaxConcurrency, "concurrency", 5, "number of pages fetched at once")
+	fs.IntVar(&opts.MaxPages, "max-pages", 100, "stop after this many pages")
+	fs.Var(&include, "include", "only crawl URLs matching one of these regular expressions (space-separated, repeatable)")
+	fs.Var(&exclude, "exclude", "never crawl URLs matching any of these regular expressions (space-separated, repeatable)")
+	fs.StringVar(&opts.Fetch.Login.URL, "login-url", "", "page with a login form to submit before crawling")
+	fs.Var(loginFields, "login-field", "login form value as name=value (repeatable)")
+	fs.Var(loginSecrets, "login-secret", "secret login form value as name=env:VAR or name=file:PATH (repeatable)")
+	fs.StringVar(&opts.Fetch.Login.SuccessURL, "login-success-url", "", "the login worked when the form leads here")
+	fs.StringVar(&opts.Fetch.Login.SuccessSelector, "login-success-selector", "", "the login worked when the resulting page contains this CSS selector")
+	fs.StringVar(&opts.Reports.OutputDir, "output-dir", ".", "directory for the reports and crawl.json")
+	fs.StringVar(&robots, "robots", string(crawler.RobotsRespect), "robots directives policy: respect or ignore")
+	fs.BoolVar(&opts.Reports.CollapseCanonicals, "collapse-canonicals", false, "fold pages into their declared canonical in report.csv")
+	fs.BoolVar(&opts.Reports.ProbeImages, "probe-images", true, "send a HEAD request to each unique image for the image report")
+	fs.Var(extract, "extract", "custom report field as name=CSS selector, optionally ending in @attribute (repeatable)")
+	fs.BoolVar(&opts.Stream, "stream", false, "keep memory bounded: write only report.csv, sorted on disk, without the other reports or crawl.json")
+	fs.StringVar(&opts.Record, "record", "", "save every response to this archive for --replay")
+	fs.StringVar(&opts.Replay, "replay", "", "crawl from an archive saved with --record instead of the network")
+	csv.register(fs)
+	analysis.register(fs)
+	fetch.register(fs)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return crawlOptions{}, err
+	}
+
+	// Positional arguments count as command line flags
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	switch len(positional) {
+	case 0:
+	case 1, 3:
+		if set["url"] && opts.URL != positional[0] {
+			return crawlOptions{}, fmt.Errorf("URL given both as --url and as an argument")
+		}
+		fs.Set("url", positional[0])
+
+		// Legacy form: <URL> <maxConcurrency> <maxPages>
+		if len(positional) == 3 {
+			if _, err := strconv.Atoi(positional[1]); err != nil {
+				return crawlOptions{}, fmt.Errorf("couldn't parse maxConcurrency: %w", err)
+			}
+			if _, err := strconv.Atoi(positional[2]); err != nil {
+				return crawlOptions{}, fmt.Errorf("couldn't parse maxPages: %w", err)
+			}
+			fs.Set("concurrency", positional[1])
+			fs.Set("max-pages", positional[2])
+		}
+	default:
+		return crawlOptions{}, fmt.Errorf("expected <URL> or <URL> <maxConcurrency> <maxPages>, got %d arguments", len(positional))
+	}
+
+	// Fill the rest from the environment, then from the profile
+	if err := applyEnv(fs); err != nil {
+		return crawlOptions{}, err
+	}
+	profile, err := applyProfile(fs, configPath)
+	if err != nil {
+		return crawlOptions{}, err
+	}
+
+	// The legacy form predates robots directives, so it keeps crawling
+	// everything unless a policy is chosen explicitly
+	if len(positional) == 3 {
+		set = make(map[string]bool)
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		if !set["robots"] {
+			robots = string(crawler.RobotsIgnore)
+		}
+	}
+
+	opts.Include = include
+	opts.Exclude = exclude
+
+	// Settings that only a profile can hold
+	opts.Normalize = profile.Normalize.options()
+	opts.Extract = mergeValues(profile.Extract, extract)
+	opts.Fetch.Login.Fields = mergeValues(profile.Login.Fields, loginFields)
+	opts.Fetch.Login.SecretFields = mergeValues(profile.Login.SecretFields, loginSecrets)
+
+	// Validate values
+	if opts.URL != "" {
+		if err := crawler.ValidateURL(opts.URL); err != nil {
+			return crawlOptions{}, err
+		}
+	}
+	if opts.MaxConcurrency < 1 {
+		return crawlOptions{}, fmt.Errorf("maxConcurrency must be at least 1")
+	}
+	if opts.MaxPages < 1 {
+		return crawlOptions{}, fmt.Errorf("maxPages must be at least 1")
+	}
+	if err := fetch.apply(&opts.Fetch, profile); err != nil {
+		return crawlOptions{}, err
+	}
+	if opts.Record != "" && opts.Replay != "" {
+		return crawlOptions{}, fmt.Errorf("--record and --replay can't be used together")
+	}
+	if err := opts.Fetch.Login.Validate(); err != nil {
+		return crawlOptions{}, err
+	}
+
+	if opts.Reports.Robots, err = crawler.ParseRobotsPolicy(robots); err != nil {
+		return crawlOptions{}, err
+	}
+	if err := analysis.apply(&opts.Reports, profile); err != nil {
+		return crawlOptions{}, err
+	}
+	extractors, err := selectorExtractors(opts.Extract)
+	if err != nil {
+		return crawlOptions{}, err
+	}
+	if opts.Reports.CSV, err = csv.options(fieldNames(extractors)); err != nil {
+		return crawlOptions{}, err
+	}
+
+	// Whole-site orders and folds need every page in memory
+	if opts.Stream && opts.Reports.CSV.SortBy == crawler.SortByInbound {
+		return crawlOptions{}, fmt.Errorf("--stream can't sort by inbound links")
+	}
+	if opts.Stream && opts.Reports.CollapseCanonicals {
+		return crawlOptions{}, fmt.Errorf("--stream can't collapse canonicals")
+	}
+
+	return opts, nil
+}
+
+// runCrawl crawls the site, then writes every report and the snapshot
+func runCrawl(opts crawlOptions, stdout, stderr io.Writer) error {
+	if err := os.MkdirAll(opts.Reports.OutputDir, 0o755); err != nil {
+		return fmt.Errorf("couldn't create output directory: %w", err)
+	}
+	reportPath := filepath.Join(opts.Reports.OutputDir, "report.csv")
+
+	// Pick where pages come from
+	var fetcher crawler.Fetcher
+	var recorder *crawler.Recorder
+	switch {
+	case opts.Replay != "":
+		replay, err := crawler.NewReplayFetcher(opts.Replay)
+		if err != nil {
+			return err
+		}
+		fetcher = replay
+	case opts.Record != "":
+		httpFetcher, err := crawler.NewHTTPFetcher(opts.Fetch)
+		if err != nil {
+			return err
+		}
+		recorder = crawler.NewRecorder(httpFetcher)
+		fetcher = recorder
+	}
+
+	// Stream pages to report.csv as they are crawled
+	csvWriter, err := crawler.NewCSVPageWriter(reportPath, opts.Reports.CSV)
+	if err != nil {
+		return fmt.Errorf("couldn't create CSV report: %w", err)
+	}
+
+	// Checked when the flags were parsed
+	extractors, _ := selectorExtractors(opts.Extract)
+
+	c, err := crawler.New(crawler.Options{
+		URL:            opts.URL,
+		MaxConcurrency: opts.MaxConcurrency,
+		MaxPages:       opts.MaxPages,
+		Include:        opts.Include,
+		Exclude:        opts.Exclude,
+		Robots:         opts.Reports.Robots,
+		Fetch:          opts.Fetch,
+		Fetcher:        fetcher,
+		Normalize:      opts.Normalize,
+		Extractors:     extractors,
+		DiscardPages:   opts.Stream,
+		OnPage: func(pageData crawler.PageData) error {
+			if err := csvWriter.WritePage(pageData); err != nil {
+				return fmt.Errorf("couldn't write CSV report: %w", err)
+			}
+			return nil
+		},
+		Log: stderr, // Keep stdout for the summary, so it can be piped
+	})
+	if err != nil {
+		csvWriter.Close()
+		return err
+	}
+
+	// Print start message
+	fmt.Fprintf(stdout, "starting crawl of: %s\n", opts.URL)
+	fmt.Fprintf(stdout, "max concurrency: %d\n", opts.MaxConcurrency)
+	fmt.Fprintf(stdout, "max pages: %d\n", opts.MaxPages)
+	fmt.Fprintln(stdout)
+
+	// Wait for the last rows to hit the disk. A streamed report is sorted
+	// in place; otherwise WriteReports rewrites it with the link scores.
+	snapshot, err := c.Run(context.Background())
+	closeWriter := csvWriter.Close
+	if opts.Stream && err == nil {
+		closeWriter = csvWriter.CloseSorted
+	}
+	closeErr := closeWriter()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return fmt.Errorf("couldn't write CSV report: %w", closeErr)
+	}
+	// Print completion message
+	fmt.Fprintln(stdout, "\n=============================")
+	fmt.Fprintln(stdout, "CRAWL COMPLETE")
+	fmt.Fprintln(stdout, "=============================")
+	fmt.Fprintf(stdout, "Found %d unique pages\n\n", len(snapshot.Pages))
+
+	// Every other report needs the pages that weren't kept
+	if opts.Stream {
+		fmt.Fprintf(stdout, "Report successfully written to %s\n", reportPath)
+		fmt.Fprintln(stdout, "Streamed crawl: skipped the link scores, the other reports and crawl.json")
+		return saveRecording(recorder, opts.Record, stdout)
+	}
+
+	// Rewrite the streamed report in a stable order and write the others.
+	// Image probes are recorded too, so save the archive afterwards.
+	if err := c.WriteReports(snapshot, opts.Reports, stdout); err != nil {
+		return err
+	}
+	if err := saveRecording(recorder, opts.Record, stdout); err != nil {
+		return err
+	}
+
+	snapshotPath := filepath.Join(opts.Reports.OutputDir, crawler.SnapshotFilename)
+	if err := crawler.SaveSnapshot(snapshot, snapshotPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Crawl saved to %s\n", snapshotPath)
+
+	return nil
+}
+
+// saveRecording writes the responses the recorder saw, if recording
+func saveRecording(recorder *crawler.Recorder, filename string, stdout io.Writer) error {
+	if recorder == nil {
+		return nil
+	}
+	if err := recorder.Save(filename); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Responses recorded to %s\n", filename)
+	return nil
+}
+
+// selectorExtractors creates an extractor per --extract field, sorted by
+// field name so the report columns keep a stable order
+func selectorExtractors(selectors map[string]string) ([]crawler.Extractor, error) {
+	names := make([]string, 0, len(selectors))
+	for name := range selectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	extractors := make([]crawler.Extractor, 0, len(names))
+	for _, name := range names {
+		extractor, err := crawler.NewSelectorExtractor(name, selectors[name])
+		if err != nil {
+			return nil, err
+		}
+		extractors = append(extractors, extractor)
+	}
+	return extractors, nil
+}
+
+// fieldNames lists the fields the extractors declare, in order
+func fieldNames(extractors []crawler.Extractor) []string {
+	var names []string
+	for _, extractor := range extractors {
+		names = append(names, extractor.Fields()...)
+	}
+	return names
+}
+
+// mergeValues combines profile and flag values; flags win
+func mergeValues(profile, flags map[string]string) map[string]string {
+	if len(profile) == 0 && len(flags) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(profile)+len(flags))
+	for name, value := range profile {
+		merged[name] = value
+	}
+	for name, value := range flags {
+		merged[name] = value
+	}
+	return merged
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Utkarsh736/linkscout/crawler"
+)
+
+const diffSummary = `Compare two saved crawls: pages added, removed, and changed fields
+such as status code, title, canonical or content hash.
+
+Usage:
+  linkscout diff [flags] <old crawl.json> <new crawl.json>
+`
+
+// runDiffCommand is the entry point of "linkscout diff"
+func runDiffCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("diff", diffSummary, stderr)
+
+	var output string
+	fs.StringVar(&output, "output", "diff.csv", "file to write the changes to")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return usageFailure(err, stderr)
+	}
+	if len(positional) != 2 {
+		return usageFailure(fmt.Errorf("expected <old crawl.json> <new crawl.json>, got %d arguments", len(positional)), stderr)
+	}
+
+	oldSnapshot, err := crawler.LoadSnapshot(positional[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitError
+	}
+	newSnapshot, err := crawler.LoadSnapshot(positional[1])
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitError
+	}
+
+	changes, err := crawler.DiffSnapshots(oldSnapshot, newSnapshot)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitError
+	}
+	if err := crawler.WriteDiffCSV(changes, output, crawler.CSVOptions{}); err != nil {
+		fmt.Fprintf(stderr, "error: couldn't write diff: %v\n", err)
+		return exitError
+	}
+
+	counts := make(map[string]int)
+	changedPages := make(map[string]bool)
+	for _, change := range changes {
+		if change.Change == crawler.ChangeChanged {
+			changedPages[change.URL] = true
+		} else {
+			counts[change.Change]++
+		}
+	}
+	fmt.Fprintf(stdout, "%d pages added, %d removed, %d changed\n", counts[crawler.ChangeAdded], counts[crawler.ChangeRemoved], len(changedPages))
+	fmt.Fprintf(stdout, "Changes written to %s\n", output)
+	return exitOK
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Utkarsh736/linkscout/crawler"
+)
+
+const reportSummary = `Regenerate every report from a saved crawl, without fetching the site again.
+
+Usage:
+  linkscout report [flags] [crawl.json]
+`
+
+// runReportCommand is the entry point of "linkscout report"
+func runReportCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("report", reportSummary, stderr)
+
+	var configPath, input, robots string
+	var opts crawler.ReportOptions
+	var csv csvFlags
+	var analysis analysisFlags
+	var fetch fetchFlags
+	fs.StringVar(&configPath, "config", "", "YAML or TOML crawl profile; its report settings apply, and flags override them")
+	fs.StringVar(&input, "input", crawler.SnapshotFilename, "crawl snapshot written by the crawl command")
+	fs.StringVar(&opts.OutputDir, "output-dir", ".", "directory for the reports")
+	fs.StringVar(&robots, "robots", string(crawler.RobotsRespect), "robots directives policy: respect or ignore")
+	fs.BoolVar(&opts.CollapseCanonicals, "collapse-canonicals", false, "fold pages into their declared canonical in report.csv")
+	fs.BoolVar(&opts.ProbeImages, "probe-images", false, "send a HEAD request to each unique image for the image report")
+	csv.register(fs)
+	analysis.register(fs)
+	fetch.register(fs)
+
+	positional, err := parseFlags(fs, args)
+	var profile crawlProfile
+	if err == nil {
+		profile, err = applyProfile(fs, configPath)
+	}
+	if err != nil {
+		return usageFailure(err, stderr)
+	}
+	switch len(positional) {
+	case 0:
+	case 1:
+		input = positional[0]
+	default:
+		return usageFailure(fmt.Errorf("expected at most one snapshot file, got %d arguments", len(positional)), stderr)
+	}
+	if opts.Robots, err = crawler.ParseRobotsPolicy(robots); err != nil {
+		return usageFailure(err, stderr)
+	}
+	if err := analysis.apply(&opts, profile); err != nil {
+		return usageFailure(err, stderr)
+	}
+	if err := fetch.apply(&opts.Fetch, profile); err != nil {
+		return usageFailure(err, stderr)
+	}
+
+	snapshot, err := crawler.LoadSnapshot(input)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitError
+	}
+
+	// The crawl's custom fields are columns too
+	if opts.CSV, err = csv.options(snapshot.Fields); err != nil {
+		return usageFailure(err, stderr)
+	}
+	if err := os.MkdirAll(opts.OutputDir, 0o755); err != nil {
+		fmt.Fprintf(stderr, "error: couldn't create output directory: %v\n", err)
+		return exitError
+	}
+
+	fmt.Fprintf(stdout, "Writing reports for %s (%d pages)\n", snapshot.BaseURL, len(snapshot.Pages))
+	if err := crawler.WriteReports(snapshot, opts, stdout); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
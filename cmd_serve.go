@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const serveSummary = `Serve a report directory over HTTP, so reports can be opened from a browser.
+
+Usage:
+  linkscout serve [flags]
+`
+
+// runServeCommand is the entry point of "linkscout serve"
+func runServeCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("serve", serveSummary, stderr)
+
+	var addr, dir string
+	fs.StringVar(&addr, "addr", "localhost:8080", "address to listen on")
+	fs.StringVar(&dir, "output-dir", ".", "report directory to serve")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return usageFailure(err, stderr)
+	}
+	if len(positional) != 0 {
+		return usageFailure(fmt.Errorf("serve takes no arguments, got %d", len(positional)), stderr)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return usageFailure(fmt.Errorf("output directory %q doesn't exist", dir), stderr)
+	}
+
+	fmt.Fprintf(stdout, "Serving %s at http://%s\n", dir, addr)
+	if err := http.ListenAndServe(addr, newReportHandler(dir)); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// newReportHandler serves the files of a report directory, with CSV
+// files marked as text so browsers show them instead of downloading
+func newReportHandler(dir string) http.Handler {
+	files := http.FileServer(http.Dir(dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".csv") {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		}
+		files.ServeHTTP(w, r)
+	})
+}
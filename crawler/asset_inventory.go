@@ -0,0 +1,106 @@
+package crawler
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// assetEntry is one asset in the site-wide inventory
+type assetEntry struct {
+	URL        string
+	Type       string
+	Host       string
+	FirstParty bool     // Served from the crawled site's registrable domain
+	Pages      []string // Pages that use the asset, sorted
+}
+
+// buildAssetInventory lists every asset used across the crawled pages with
+// the pages that use it, sorted by type then URL. An asset referenced both
+// directly and through a hint is listed under the type it was loaded as.
+func buildAssetInventory(pages map[string]PageData, siteURL *url.URL) []assetEntry {
+	entries := make(map[string]*assetEntry)
+
+	for _, pageData := range sortPages(pages, SortByURL) {
+		for _, asset := range pageData.Assets {
+			entry, exists := entries[asset.URL]
+			if !exists {
+				entry = &assetEntry{URL: asset.URL, Type: asset.Type}
+				if assetURL, err := url.Parse(asset.URL); err == nil {
+					entry.Host = strings.ToLower(assetURL.Hostname())
+					entry.FirstParty = isFirstParty(entry.Host, siteURL.Hostname())
+				}
+				entries[asset.URL] = entry
+			} else if asset.Hint == "" {
+				entry.Type = asset.Type
+			}
+
+			// Pages come in URL order, so only the last one can repeat
+			if n := len(entry.Pages); n == 0 || entry.Pages[n-1] != pageData.URL {
+				entry.Pages = append(entry.Pages, pageData.URL)
+			}
+		}
+	}
+
+	inventory := make([]assetEntry, 0, len(entries))
+	for _, entry := range entries {
+		inventory = append(inventory, *entry)
+	}
+	sort.Slice(inventory, func(i, j int) bool {
+		if inventory[i].Type != inventory[j].Type {
+			return inventory[i].Type < inventory[j].Type
+		}
+		return inventory[i].URL < inventory[j].URL
+	})
+
+	return inventory
+}
+
+// isFirstParty reports whether host belongs to the same registrable domain
+// as the site, so cdn.example.com counts as first-party for www.example.com
+func isFirstParty(host, siteHost string) bool {
+	host, siteHost = strings.ToLower(host), strings.ToLower(siteHost)
+	if host == siteHost {
+		return true
+	}
+
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return false
+	}
+	siteDomain, err := publicsuffix.EffectiveTLDPlusOne(siteHost)
+	if err != nil {
+		return false
+	}
+	return domain == siteDomain
+}
+
+// assetsHeader is the column layout of the asset inventory
+var assetsHeader = []string{"asset_url", "type", "party", "host", "page_count", "pages"}
+
+// writeAssetsCSV writes one row per asset. The pages column is joined with
+// the multi-value separator.
+func writeAssetsCSV(inventory []assetEntry, filename string, opts CSVOptions) error {
+	opts = opts.WithDefaults()
+
+	rows := make([][]string, len(inventory))
+	for i, entry := range inventory {
+		party := "third"
+		if entry.FirstParty {
+			party = "first"
+		}
+		rows[i] = []string{
+			entry.URL,
+			entry.Type,
+			party,
+			entry.Host,
+			strconv.Itoa(len(entry.Pages)),
+			strings.Join(entry.Pages, opts.MultiSeparator),
+		}
+	}
+
+	return writeCSVRows(filename, opts, assetsHeader, rows)
+}
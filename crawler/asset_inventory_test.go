@@ -0,0 +1,126 @@
+package crawler
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGetAssetsFromHTML(t *testing.T) {
+	inputBody := `<html><head>
+		<link rel="stylesheet" href="/css/site.css">
+		<link rel="preload" href="/fonts/inter.woff2" as="font" crossorigin>
+		<link rel="prefetch" href="/next-page">
+		<link rel="modulepreload" href="/js/app.mjs">
+		<link rel="shortcut icon" href="/favicon.ico">
+		<link rel="manifest" href="/site.webmanifest">
+		<script src="https://www.googletagmanager.com/gtag/js"></script>
+		<script>inline()</script>
+		<style>@font-face { font-family: Inter; src: url("/fonts/inter.woff") format("woff"); }</style>
+	</head><body>
+		<iframe src="https://www.youtube.com/embed/abc"></iframe>
+		<iframe src="about:blank"></iframe>
+		<video poster="/poster.jpg"><source src="/intro.mp4" type="video/mp4"><track src="/intro.vtt"></video>
+		<audio src="/podcast.mp3"></audio>
+	</body></html>`
+
+	baseURL, err := url.Parse("https://example.com")
+	if err != nil {
+		t.Fatalf("couldn't parse input URL: %v", err)
+	}
+
+	actual, err := getAssetsFromHTML(inputBody, baseURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Asset{
+		{URL: "https://example.com/css/site.css", Type: assetStylesheet},
+		{URL: "https://example.com/fonts/inter.woff2", Type: assetFont, Hint: "preload"},
+		{URL: "https://example.com/next-page", Type: assetOther, Hint: "prefetch"},
+		{URL: "https://example.com/js/app.mjs", Type: assetScript, Hint: "modulepreload"},
+		{URL: "https://example.com/favicon.ico", Type: assetFavicon},
+		{URL: "https://example.com/site.webmanifest", Type: assetManifest},
+		{URL: "https://www.googletagmanager.com/gtag/js", Type: assetScript},
+		{URL: "https://example.com/fonts/inter.woff", Type: assetFont},
+		{URL: "https://www.youtube.com/embed/abc", Type: assetIframe},
+		{URL: "https://example.com/intro.mp4", Type: assetVideo},
+		{URL: "https://example.com/intro.vtt", Type: assetVideo},
+		{URL: "https://example.com/podcast.mp3", Type: assetAudio},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestBuildAssetInventory(t *testing.T) {
+	pages := map[string]PageData{
+		"www.example.com": {
+			URL: "https://www.example.com",
+			Assets: []Asset{
+				{URL: "https://cdn.example.com/app.js", Type: assetScript},
+				{URL: "https://www.example.com/site.css", Type: assetStylesheet},
+				{URL: "https://fonts.example.org/a.woff2", Type: assetFont, Hint: "preload"},
+			},
+		},
+		"www.example.com/about": {
+			URL: "https://www.example.com/about",
+			Assets: []Asset{
+				{URL: "https://cdn.example.com/app.js", Type: assetScript},
+				{URL: "https://cdn.example.com/app.js", Type: assetScript, Hint: "preload"},
+			},
+		},
+	}
+
+	siteURL, _ := url.Parse("https://www.example.com")
+	inventory := buildAssetInventory(pages, siteURL)
+
+	expected := []assetEntry{
+		{URL: "https://fonts.example.org/a.woff2", Type: assetFont, Host: "fonts.example.org", Pages: []string{"https://www.example.com"}},
+		{URL: "https://cdn.example.com/app.js", Type: assetScript, Host: "cdn.example.com", FirstParty: true, Pages: []string{"https://www.example.com", "https://www.example.com/about"}},
+		{URL: "https://www.example.com/site.css", Type: assetStylesheet, Host: "www.example.com", FirstParty: true, Pages: []string{"https://www.example.com"}},
+	}
+	if !reflect.DeepEqual(inventory, expected) {
+		t.Errorf("expected %+v, got %+v", expected, inventory)
+	}
+
+	filename := filepath.Join(t.TempDir(), "assets.csv")
+	if err := writeAssetsCSV(inventory, filename, CSVOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	actual, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("couldn't read report: %v", err)
+	}
+
+	expectedCSV := "asset_url,type,party,host,page_count,pages\n" +
+		"https://fonts.example.org/a.woff2,font,third,fonts.example.org,1,https://www.example.com\n" +
+		"https://cdn.example.com/app.js,script,first,cdn.example.com,2,https://www.example.com;https://www.example.com/about\n" +
+		"https://www.example.com/site.css,stylesheet,first,www.example.com,1,https://www.example.com\n"
+	if string(actual) != expectedCSV {
+		t.Errorf("expected:\n%s\ngot:\n%s", expectedCSV, actual)
+	}
+}
+
+func TestIsFirstParty(t *testing.T) {
+	tests := []struct {
+		host     string
+		siteHost string
+		expected bool
+	}{
+		{"example.com", "example.com", true},
+		{"cdn.example.com", "www.example.com", true},
+		{"example.com.evil.net", "example.com", false},
+		{"a.github.io", "b.github.io", false}, // Public suffix: different owners
+		{"shop.example.co.uk", "example.co.uk", true},
+		{"localhost", "localhost", true},
+	}
+
+	for _, tc := range tests {
+		if actual := isFirstParty(tc.host, tc.siteHost); actual != tc.expected {
+			t.Errorf("isFirstParty(%q, %q): expected %v, got %v", tc.host, tc.siteHost, tc.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package crawler
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// AuthScheme is the kind of credentials sent to a host
+type AuthScheme string
+
+const (
+	AuthBasic  AuthScheme = "basic"  // The secret is "user:password"
+	AuthBearer AuthScheme = "bearer" // The secret is the token
+)
+
+// ParseAuthScheme converts a user-supplied scheme name into an AuthScheme
+func ParseAuthScheme(raw string) (AuthScheme, error) {
+	switch scheme := AuthScheme(strings.ToLower(raw)); scheme {
+	case AuthBasic, AuthBearer:
+		return scheme, nil
+	default:
+		return "", fmt.Errorf("unknown auth type %q (expected basic or bearer)", raw)
+	}
+}
+
+// AuthSpec says where to find the credentials for a host. The secret
+// itself only ever lives in the environment or a file, so specs are safe
+// to print and save.
+type AuthSpec struct {
+	Host   string // Host name, optionally with a port; "*.example.com" also matches subdomains
+	Scheme AuthScheme
+	Env    string // Environment variable holding the secret
+	File   string // File holding the secret; surrounding whitespace is ignored
+}
+
+// String formats the spec the way --auth takes it
+func (s AuthSpec) String() string {
+	if s.Env != "" {
+		return fmt.Sprintf("%s=%s:env:%s", s.Host, s.Scheme, s.Env)
+	}
+	return fmt.Sprintf("%s=%s:file:%s", s.Host, s.Scheme, s.File)
+}
+
+// ParseAuthSpec parses "HOST=TYPE:env:VAR" or "HOST=TYPE:file:PATH"
+func ParseAuthSpec(raw string) (AuthSpec, error) {
+	host, rest, ok := strings.Cut(raw, "=")
+	parts := strings.SplitN(rest, ":", 3)
+	if !ok || len(parts) != 3 {
+		return AuthSpec{}, fmt.Errorf("invalid auth %q (expected HOST=TYPE:env:VAR or HOST=TYPE:file:PATH)", raw)
+	}
+
+	spec := AuthSpec{Host: host}
+	switch parts[1] {
+	case "env":
+		spec.Env = parts[2]
+	case "file":
+		spec.File = parts[2]
+	default:
+		return AuthSpec{}, fmt.Errorf("invalid auth source %q (expected env or file)", parts[1])
+	}
+
+	var err error
+	if spec.Scheme, err = ParseAuthScheme(parts[0]); err != nil {
+		return AuthSpec{}, err
+	}
+	return spec, spec.Validate()
+}
+
+// Validate checks that the spec names a host and exactly one secret source
+func (s AuthSpec) Validate() error {
+	if s.Host == "" {
+		return fmt.Errorf("auth host is empty")
+	}
+	if (s.Env == "") == (s.File == "") {
+		return fmt.Errorf("auth for %s needs exactly one of env or file", s.Host)
+	}
+	return nil
+}
+
+// readSecret reads a secret from an environment variable, or from a file
+// when env is empty. Errors never include the secret.
+func readSecret(env, file string) (string, error) {
+	if env != "" {
+		value, ok := os.LookupEnv(env)
+		if !ok || value == "" {
+			return "", fmt.Errorf("%s is not set", env)
+		}
+		return value, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ParseSecretSource parses "env:VAR" or "file:PATH"
+func ParseSecretSource(raw string) (env, file string, err error) {
+	source, name, _ := strings.Cut(raw, ":")
+	switch {
+	case name == "":
+		return "", "", fmt.Errorf("invalid secret source %q (expected env:VAR or file:PATH)", raw)
+	case source == "env":
+		return name, "", nil
+	case source == "file":
+		return "", name, nil
+	default:
+		return "", "", fmt.Errorf("invalid secret source %q (expected env:VAR or file:PATH)", raw)
+	}
+}
+
+// hostCredential is a resolved Authorization header for one host
+type hostCredential struct {
+	host          string
+	authorization string
+}
+
+// loadCredentials reads every secret from its environment variable or
+// file. Errors name the source, never the secret.
+func loadCredentials(specs []AuthSpec) ([]hostCredential, error) {
+	credentials := make([]hostCredential, 0, len(specs))
+	for _, spec := range specs {
+		if err := spec.Validate(); err != nil {
+			return nil, err
+		}
+
+		secret, err := readSecret(spec.Env, spec.File)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read credentials for %s: %w", spec.Host, err)
+		}
+
+		var authorization string
+		switch spec.Scheme {
+		case AuthBasic:
+			if !strings.Contains(secret, ":") {
+				return nil, fmt.Errorf("basic credentials for %s must look like user:password", spec.Host)
+			}
+			authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(secret))
+		case AuthBearer:
+			authorization = "Bearer " + secret
+		default:
+			return nil, fmt.Errorf("unknown auth type %q for %s", spec.Scheme, spec.Host)
+		}
+		credentials = append(credentials, hostCredential{host: strings.ToLower(spec.Host), authorization: authorization})
+	}
+	return credentials, nil
+}
+
+// matches reports whether the credential applies to a URL's host. A host
+// without a port matches any port.
+func (c hostCredential) matches(u *url.URL) bool {
+	host := strings.ToLower(u.Host)
+	name := strings.ToLower(u.Hostname())
+	if suffix, ok := strings.CutPrefix(c.host, "*."); ok {
+		return strings.HasSuffix(name, "."+suffix) || strings.HasSuffix(host, "."+suffix)
+	}
+	return c.host == host || c.host == name
+}
+
+// authorize sets the Authorization header from the first credential that
+// matches the request's host
+func authorize(req *http.Request, credentials []hostCredential) {
+	for _, credential := range credentials {
+		if credential.matches(req.URL) {
+			req.Header.Set("Authorization", credential.authorization)
+			return
+		}
+	}
+}
+
+// secretHeaders are request headers whose values are never printed
+var secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// RedactHeaders returns a copy of headers with secret values hidden
+func RedactHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(headers))
+	for name, value := range headers {
+		for _, secret := range secretHeaders {
+			if strings.EqualFold(name, secret) {
+				value = "REDACTED"
+			}
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
@@ -0,0 +1,121 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseAuthSpec(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected AuthSpec
+		errText  string
+	}{
+		{
+			name:     "basic from env",
+			raw:      "staging.example.com=basic:env:STAGING_CREDS",
+			expected: AuthSpec{Host: "staging.example.com", Scheme: AuthBasic, Env: "STAGING_CREDS"},
+		},
+		{
+			name:     "bearer from file with a colon in the path",
+			raw:      "*.example.com=Bearer:file:C:/secrets/token",
+			expected: AuthSpec{Host: "*.example.com", Scheme: AuthBearer, File: "C:/secrets/token"},
+		},
+		{name: "missing host", raw: "=basic:env:X", errText: "auth host is empty"},
+		{name: "unknown type", raw: "example.com=digest:env:X", errText: "unknown auth type"},
+		{name: "unknown source", raw: "example.com=basic:vault:X", errText: "invalid auth source"},
+		{name: "inline secret", raw: "example.com=user:password", errText: "invalid auth"},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := ParseAuthSpec(tc.raw)
+			if tc.errText != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errText) {
+					t.Errorf("Test %v - %s FAIL: expected error containing %q, got %v", i, tc.name, tc.errText, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+			}
+			if actual != tc.expected {
+				t.Errorf("Test %v - %s FAIL: expected %+v, got %+v", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLoadCredentialsKeepsSecretsOutOfErrors(t *testing.T) {
+	t.Setenv("LINKSCOUT_TEST_TOKEN", "no-colon-secret")
+
+	_, err := loadCredentials([]AuthSpec{{Host: "example.com", Scheme: AuthBasic, Env: "LINKSCOUT_TEST_TOKEN"}})
+	if err == nil || strings.Contains(err.Error(), "no-colon-secret") {
+		t.Errorf("expected an error without the secret, got %v", err)
+	}
+
+	_, err = loadCredentials([]AuthSpec{{Host: "example.com", Scheme: AuthBearer, Env: "LINKSCOUT_TEST_UNSET"}})
+	if err == nil || !strings.Contains(err.Error(), "LINKSCOUT_TEST_UNSET is not set") {
+		t.Errorf("expected a missing variable error, got %v", err)
+	}
+}
+
+func TestFetcherCredentialsPerHost(t *testing.T) {
+	var protected, public []string
+	protectedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		protected = append(protected, r.Header.Get("Authorization"))
+	}))
+	defer protectedServer.Close()
+	publicServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		public = append(public, r.Header.Get("Authorization"))
+	}))
+	defer publicServer.Close()
+
+	// Both servers listen on 127.0.0.1, so the port tells them apart
+	protectedURL, _ := url.Parse(protectedServer.URL)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("s3cret-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LINKSCOUT_TEST_BASIC", "deploy:hunter2")
+
+	tests := []struct {
+		name     string
+		spec     AuthSpec
+		expected string
+	}{
+		{name: "bearer from file", spec: AuthSpec{Host: protectedURL.Host, Scheme: AuthBearer, File: tokenFile}, expected: "Bearer s3cret-token"},
+		{name: "basic from env", spec: AuthSpec{Host: protectedURL.Host, Scheme: AuthBasic, Env: "LINKSCOUT_TEST_BASIC"}, expected: "Basic ZGVwbG95Omh1bnRlcjI="},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			protected, public = nil, nil
+			fetcher, err := newHTTPFetcher(FetchOptions{Auth: []AuthSpec{tc.spec}})
+			if err != nil {
+				t.Fatalf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+			}
+			for _, target := range []string{protectedServer.URL, publicServer.URL} {
+				req, _ := fetcher.newRequest("GET", target)
+				resp, err := fetcher.do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+			}
+
+			if len(protected) != 1 || protected[0] != tc.expected {
+				t.Errorf("Test %v - %s FAIL: expected %q on the protected host, got %v", i, tc.name, tc.expected, protected)
+			}
+			if len(public) != 1 || public[0] != "" {
+				t.Errorf("Test %v - %s FAIL: expected no credentials on the other host, got %v", i, tc.name, public)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package crawler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Canonical conflict kinds
+const (
+	canonicalNonSelf    = "non_self"    // Page names another URL as canonical
+	canonicalChain      = "chain"       // The canonical page itself names a different canonical
+	canonicalRedirect   = "redirect"    // The canonical URL redirects
+	canonicalBroken     = "broken"      // The canonical URL returned an HTTP error
+	canonicalNotCrawled = "not_crawled" // The canonical URL was never crawled, so it can't be checked
+)
+
+// canonicalEntry is one page's canonical declaration and its problems
+type canonicalEntry struct {
+	URL       string
+	Canonical string   // Declared canonical URL
+	Self      bool     // Canonical is the page itself
+	Conflicts []string // Conflict kinds, in the order above
+	Detail    string
+}
+
+// canonicalKey returns the pages-map key of a page's declared canonical
+func canonicalKey(pageData PageData, n *urlNormalizer) (string, bool) {
+	if pageData.Canonical == "" {
+		return "", false
+	}
+	key, err := n.normalize(pageData.Canonical)
+	if err != nil {
+		return "", false
+	}
+	return key, true
+}
+
+// analyzeCanonicals checks every page that declares a canonical, sorted by
+// canonical URL then page URL so pages sharing a canonical sit together
+func analyzeCanonicals(pages map[string]PageData, n *urlNormalizer) []canonicalEntry {
+	var entries []canonicalEntry
+
+	for key, pageData := range pages {
+		target, ok := canonicalKey(pageData, n)
+		if !ok {
+			continue
+		}
+
+		entry := canonicalEntry{
+			URL:       pageData.URL,
+			Canonical: pageData.Canonical,
+			Self:      target == key,
+		}
+
+		var details []string
+		if !entry.Self {
+			entry.Conflicts = append(entry.Conflicts, canonicalNonSelf)
+		}
+
+		canonicalPage, crawled := pages[target]
+		switch {
+		case !crawled:
+			entry.Conflicts = append(entry.Conflicts, canonicalNotCrawled)
+		default:
+			if next, ok := canonicalKey(canonicalPage, n); ok && next != target {
+				entry.Conflicts = append(entry.Conflicts, canonicalChain)
+				details = append(details, "canonical page points on to "+canonicalPage.Canonical)
+			}
+			if canonicalPage.RedirectedTo != "" {
+				entry.Conflicts = append(entry.Conflicts, canonicalRedirect)
+				details = append(details, "canonical redirects to "+canonicalPage.RedirectedTo)
+			}
+			if canonicalPage.StatusCode >= 400 {
+				entry.Conflicts = append(entry.Conflicts, canonicalBroken)
+				details = append(details, fmt.Sprintf("canonical returned status %d", canonicalPage.StatusCode))
+			}
+		}
+		entry.Detail = strings.Join(details, "; ")
+
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Canonical != entries[j].Canonical {
+			return entries[i].Canonical < entries[j].Canonical
+		}
+		return entries[i].URL < entries[j].URL
+	})
+
+	return entries
+}
+
+// collapseByCanonical returns the pages with duplicates folded into their
+// canonical page. A page is folded only when its canonical was crawled
+// without errors; the canonical page lists the folded URLs in CanonicalVariants.
+func collapseByCanonical(pages map[string]PageData, n *urlNormalizer) map[string]PageData {
+	collapsed := make(map[string]PageData, len(pages))
+	for key, pageData := range pages {
+		collapsed[key] = pageData
+	}
+
+	for _, pageData := range sortPages(pages, SortByURL) {
+		key, err := n.normalize(pageData.URL)
+		if err != nil {
+			continue
+		}
+		target, ok := canonicalKey(pageData, n)
+		if !ok || target == key {
+			continue
+		}
+		if _, present := collapsed[key]; !present {
+			continue
+		}
+
+		target = followCanonicals(pages, target, n)
+		if target == key {
+			continue
+		}
+
+		canonicalPage, crawled := collapsed[target]
+		if !crawled || canonicalPage.StatusCode >= 400 {
+			continue
+		}
+
+		// Carry along anything already folded into this page, so chains end up at the last canonical
+		variants := append([]string{pageData.URL}, collapsed[key].CanonicalVariants...)
+		canonicalPage.CanonicalVariants = append(canonicalPage.CanonicalVariants, variants...)
+		collapsed[target] = canonicalPage
+		delete(collapsed, key)
+	}
+
+	return collapsed
+}
+
+// followCanonicals walks a canonical chain from key to the last crawled,
+// non-error page, stopping at loops
+func followCanonicals(pages map[string]PageData, key string, n *urlNormalizer) string {
+	seen := map[string]bool{key: true}
+	for {
+		pageData, crawled := pages[key]
+		if !crawled || pageData.StatusCode >= 400 {
+			return key
+		}
+		next, ok := canonicalKey(pageData, n)
+		if !ok || seen[next] {
+			return key
+		}
+		if nextPage, crawled := pages[next]; !crawled || nextPage.StatusCode >= 400 {
+			return key
+		}
+		seen[next] = true
+		key = next
+	}
+}
+
+// canonicalsHeader is the column layout of the canonicals report
+var canonicalsHeader = []string{"canonical_url", "page_url", "relation", "conflicts", "detail"}
+
+// writeCanonicalsCSV writes one row per page that declares a canonical
+func writeCanonicalsCSV(entries []canonicalEntry, filename string, opts CSVOptions) error {
+	opts = opts.WithDefaults()
+
+	rows := make([][]string, len(entries))
+	for i, entry := range entries {
+		relation := "variant"
+		if entry.Self {
+			relation = "self"
+		}
+		rows[i] = []string{
+			entry.Canonical,
+			entry.URL,
+			relation,
+			strings.Join(entry.Conflicts, opts.MultiSeparator),
+			entry.Detail,
+		}
+	}
+
+	return writeCSVRows(filename, opts, canonicalsHeader, rows)
+}
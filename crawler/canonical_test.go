@@ -0,0 +1,121 @@
+package crawler
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func testCanonicalPages() map[string]PageData {
+	return map[string]PageData{
+		"example.com/post": {
+			URL:        "https://example.com/post",
+			StatusCode: 200,
+			Canonical:  "https://example.com/post",
+		},
+		"example.com/post?ref=mail": {
+			URL:        "https://example.com/post?ref=mail",
+			StatusCode: 200,
+			Canonical:  "https://example.com/post",
+		},
+		"example.com/print": {
+			URL:        "https://example.com/print",
+			StatusCode: 200,
+			Canonical:  "https://example.com/old",
+		},
+		"example.com/old": {
+			URL:          "https://example.com/old",
+			StatusCode:   200,
+			RedirectedTo: "https://example.com/new",
+			Canonical:    "https://example.com/new",
+		},
+		"example.com/new": {
+			URL:        "https://example.com/new",
+			StatusCode: 200,
+		},
+		"example.com/bad": {
+			URL:        "https://example.com/bad",
+			StatusCode: 200,
+			Canonical:  "https://example.com/gone",
+		},
+		"example.com/gone": {URL: "https://example.com/gone", StatusCode: 404},
+		"example.com/lost": {
+			URL:        "https://example.com/lost",
+			StatusCode: 200,
+			Canonical:  "https://elsewhere.com/",
+		},
+	}
+}
+
+func TestAnalyzeCanonicals(t *testing.T) {
+	// The query-string variant normalizes onto the same key, so drop it here
+	pages := testCanonicalPages()
+	delete(pages, "example.com/post?ref=mail")
+
+	entries := analyzeCanonicals(pages, nil)
+
+	actual := map[string][]string{}
+	for _, entry := range entries {
+		actual[entry.URL] = entry.Conflicts
+	}
+
+	expected := map[string][]string{
+		"https://example.com/post":  nil,
+		"https://example.com/print": {canonicalNonSelf, canonicalChain, canonicalRedirect},
+		"https://example.com/old":   {canonicalNonSelf},
+		"https://example.com/bad":   {canonicalNonSelf, canonicalBroken},
+		"https://example.com/lost":  {canonicalNonSelf, canonicalNotCrawled},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	// Grouped by canonical URL
+	var canonicals []string
+	for _, entry := range entries {
+		canonicals = append(canonicals, entry.Canonical)
+	}
+	if !sort.StringsAreSorted(canonicals) {
+		t.Errorf("expected entries sorted by canonical, got %v", canonicals)
+	}
+}
+
+func TestCollapseByCanonical(t *testing.T) {
+	pages := testCanonicalPages()
+	delete(pages, "example.com/post?ref=mail")
+
+	collapsed := collapseByCanonical(pages, nil)
+
+	var keys []string
+	for key := range collapsed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	expected := []string{"example.com/bad", "example.com/gone", "example.com/lost", "example.com/new", "example.com/post"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected %v, got %v", expected, keys)
+	}
+
+	variants := collapsed["example.com/new"].CanonicalVariants
+	sort.Strings(variants)
+	if !reflect.DeepEqual(variants, []string{"https://example.com/old", "https://example.com/print"}) {
+		t.Errorf("expected the chain to collapse onto the final canonical, got %v", variants)
+	}
+
+	if len(pages["example.com/new"].CanonicalVariants) != 0 {
+		t.Errorf("collapsing must not modify the input pages")
+	}
+}
+
+func TestReportPagesCollapseCanonicals(t *testing.T) {
+	cfg := &config{pages: testCanonicalPages(), collapseCanonicals: true}
+
+	pages := cfg.reportPages()
+	if _, ok := pages["example.com/print"]; ok {
+		t.Errorf("expected example.com/print to be folded into its canonical")
+	}
+	if len(cfg.pages) != len(testCanonicalPages()) {
+		t.Errorf("collapsing the report must not drop crawled pages")
+	}
+}
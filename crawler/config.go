@@ -0,0 +1,113 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sync"
+	"sync/atomic"
+)
+
+type config struct {
+	pages              map[string]PageData // Crawled pages keyed by normalized URL
+	baseURL            *url.URL
+	mu                 *sync.Mutex
+	concurrencyControl chan struct{}
+	wg                 *sync.WaitGroup
+	maxPages           int
+	sink               *reportSink  // Optional: receives each page as soon as it's crawled
+	retainPages        bool         // Keep full PageData in pages (false keeps only the URL for dedup)
+	robotsPolicy       RobotsPolicy // Empty behaves like RobotsIgnore
+	collapseCanonicals bool         // Fold pages into their declared canonical in the page report
+	scope              urlScope     // Include and exclude rules for links; the base URL is always crawled
+	ctx                context.Context
+	fetcher            Fetcher
+	extractors         []Extractor    // Registered extractors, then the crawl's own
+	log                io.Writer      // Progress and per-page errors
+	normalizer         *urlNormalizer // Turns URLs into page keys; nil means the default pipeline
+	nofollowSkipped    atomic.Int64   // Links not followed because of nofollow directives
+	noindexSkipped     atomic.Int64   // Pages left out of the page report because of noindex
+}
+
+// logf writes a progress or error line to the crawl log
+func (cfg *config) logf(format string, args ...any) {
+	fmt.Fprintf(cfg.log, format, args...)
+}
+
+// addPageVisit safely adds a page visit to the map
+// Returns true if this is the first visit to this page
+func (cfg *config) addPageVisit(normalizedURL string, pageData PageData) (isFirst bool) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+
+	// Check if page already exists
+	if _, exists := cfg.pages[normalizedURL]; exists {
+		// Already visited - don't update, just return false
+		return false
+	}
+
+	// First visit - add to map
+	if cfg.retainPages {
+		cfg.pages[normalizedURL] = pageData
+	} else {
+		// Pages are being streamed to the sink, so only remember the URL
+		cfg.pages[normalizedURL] = PageData{URL: pageData.URL}
+	}
+	return true
+}
+
+// linksToFollow returns the outgoing links the crawler may enqueue: only
+// links that may be HTML pages, never downloads, images or media, and only
+// within the include and exclude rules.
+// When respecting robots directives, nofollow pages and rel=nofollow links are skipped.
+func (cfg *config) linksToFollow(pageData PageData) []string {
+	respect := cfg.robotsPolicy == RobotsRespect
+
+	var urls []string
+	for _, link := range pageLinks(pageData) {
+		if link.Kind != LinkPage {
+			continue
+		}
+		if !cfg.scope.allows(link.URL) {
+			continue
+		}
+		if respect && (pageData.Robots.NoFollow || link.HasRel("nofollow")) {
+			cfg.nofollowSkipped.Add(1)
+			continue
+		}
+		urls = append(urls, link.URL)
+	}
+	return urls
+}
+
+// logRobotsSkips says how many links and pages robots directives kept out
+// of the crawl, since a respected nofollow is otherwise silent
+func (cfg *config) logRobotsSkips() {
+	links, pages := cfg.nofollowSkipped.Load(), cfg.noindexSkipped.Load()
+	if links == 0 && pages == 0 {
+		return
+	}
+	cfg.logf("notice: robots directives kept %d nofollow links from being followed and %d noindex pages out of the page report; use the ignore robots policy to include them\n", links, pages)
+}
+
+// shouldReport reports whether a page belongs in the page report.
+// Noindex pages are left out when respecting robots directives.
+func (cfg *config) shouldReport(pageData PageData) bool {
+	return cfg.robotsPolicy != RobotsRespect || !pageData.Robots.NoIndex
+}
+
+// reportPages returns the crawled pages that belong in the page report,
+// folded under their canonical URL when collapseCanonicals is set
+func (cfg *config) reportPages() map[string]PageData {
+	pages := make(map[string]PageData, len(cfg.pages))
+	for key, pageData := range cfg.pages {
+		if cfg.shouldReport(pageData) {
+			pages[key] = pageData
+		}
+	}
+	if cfg.collapseCanonicals {
+		return collapseByCanonical(pages, cfg.normalizer)
+	}
+	return pages
+}
@@ -0,0 +1,88 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// simHash is a 64-bit SimHash fingerprint of a page's visible text.
+// Similar texts produce fingerprints that differ in few bits.
+type simHash uint64
+
+// String formats the fingerprint as 16 hex digits for reports
+func (h simHash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// similarity returns the share of matching bits between two fingerprints, from 0 to 1
+func (h simHash) similarity(other simHash) float64 {
+	return 1 - float64(bits.OnesCount64(uint64(h^other)))/64
+}
+
+// simHashShingleSize is the number of words in each SimHash feature
+const simHashShingleSize = 3
+
+// visibleTextFromDocument returns the text a visitor would read: the body
+// without scripts, styles and templates, with whitespace collapsed
+func visibleTextFromDocument(doc *goquery.Document) string {
+	body := doc.Find("body").Clone()
+	body.Find("script, style, noscript, template").Remove()
+	return collapseWhitespace(body.Text())
+}
+
+// contentWords splits text into lowercase words
+func contentWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// contentHash returns a SHA-256 of the normalized words, so pages differing
+// only in whitespace, case or punctuation hash the same
+func contentHash(words []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(words, " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// computeSimHash fingerprints the words using overlapping word shingles
+func computeSimHash(words []string) simHash {
+	if len(words) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	addFeature := func(feature string) {
+		hasher := fnv.New64a()
+		hasher.Write([]byte(feature))
+		sum := hasher.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	if len(words) < simHashShingleSize {
+		addFeature(strings.Join(words, " "))
+	}
+	for i := 0; i+simHashShingleSize <= len(words); i++ {
+		addFeature(strings.Join(words[i:i+simHashShingleSize], " "))
+	}
+
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return simHash(fingerprint)
+}
@@ -0,0 +1,112 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// newCookieJar creates the jar that keeps Set-Cookie values between
+// requests, loading a Netscape cookies.txt file first when path is set
+func newCookieJar(path string) (http.CookieJar, error) {
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create cookie jar: %w", err)
+	}
+	if path == "" {
+		return jar, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open cookies file: %w", err)
+	}
+	defer file.Close()
+
+	cookies, err := parseNetscapeCookies(file, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read cookies file %s: %w", path, err)
+	}
+	for _, cookie := range cookies {
+		jar.SetCookies(cookie.url, []*http.Cookie{cookie.cookie})
+	}
+	return jar, nil
+}
+
+// netscapeCookie is one cookies.txt entry and the URL it was set for
+type netscapeCookie struct {
+	url    *url.URL
+	cookie *http.Cookie
+}
+
+// parseNetscapeCookies reads the tab-separated cookies.txt format written by
+// browsers and curl: domain, include subdomains, path, secure, expiry,
+// name and value. Expired cookies are skipped. Values never appear in errors.
+func parseNetscapeCookies(r io.Reader, now time.Time) ([]netscapeCookie, error) {
+	var cookies []netscapeCookie
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		// Only the line ending goes: an empty value leaves a trailing tab
+		line := strings.TrimRight(scanner.Text(), "\r\n")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		// curl marks HttpOnly cookies with a prefix that looks like a comment
+		httpOnly := false
+		if rest, ok := strings.CutPrefix(line, "#HttpOnly_"); ok {
+			line, httpOnly = rest, true
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: expected 7 tab-separated fields, got %d", lineNo, len(fields))
+		}
+		domain, subdomains, path, secure, expiry, name, value := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], fields[6]
+
+		expires, err := strconv.ParseInt(expiry, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry %q", lineNo, expiry)
+		}
+		cookie := &http.Cookie{
+			Name:     name,
+			Value:    value,
+			Path:     path,
+			Secure:   strings.EqualFold(secure, "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if expires != 0 {
+			cookie.Expires = time.Unix(expires, 0)
+			if cookie.Expires.Before(now) {
+				continue
+			}
+		}
+
+		// A domain cookie also goes to subdomains; a host cookie doesn't
+		host := strings.TrimPrefix(domain, ".")
+		if strings.EqualFold(subdomains, "TRUE") {
+			cookie.Domain = host
+		}
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+		cookies = append(cookies, netscapeCookie{url: &url.URL{Scheme: scheme, Host: host, Path: path}, cookie: cookie})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cookies, nil
+}
@@ -0,0 +1,96 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseNetscapeCookies(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	file := strings.Join([]string{
+		"# Netscape HTTP Cookie File",
+		"",
+		".example.com\tTRUE\t/\tTRUE\t0\tsession\tabc",
+		"#HttpOnly_staging.example.com\tFALSE\t/app\tFALSE\t1800000000\tauth\txyz",
+		"old.example.com\tFALSE\t/\tFALSE\t1600000000\texpired\tgone",
+		"example.com\tFALSE\t/\tFALSE\t0\tconsent\t\r",
+	}, "\n")
+
+	cookies, err := parseNetscapeCookies(strings.NewReader(file), now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		url      string
+		name     string
+		domain   string
+		value    string
+		httpOnly bool
+	}{
+		{url: "https://example.com/", name: "session", domain: "example.com", value: "abc"},
+		{url: "http://staging.example.com/app", name: "auth", value: "xyz", httpOnly: true},
+		{url: "http://example.com/", name: "consent"},
+	}
+	if len(cookies) != len(tests) {
+		t.Fatalf("expected %d cookies, got %d", len(tests), len(cookies))
+	}
+	for i, tc := range tests {
+		actual := cookies[i]
+		if actual.url.String() != tc.url || actual.cookie.Name != tc.name || actual.cookie.Domain != tc.domain || actual.cookie.Value != tc.value || actual.cookie.HttpOnly != tc.httpOnly {
+			t.Errorf("Test %v - %s FAIL: got url %s, cookie %+v", i, tc.name, actual.url, actual.cookie)
+		}
+	}
+
+	// Malformed lines are reported by number, without the cookie value
+	_, err = parseNetscapeCookies(strings.NewReader("example.com\tFALSE\t/\tsecret-value"), now)
+	if err == nil || !strings.Contains(err.Error(), "line 1") || strings.Contains(err.Error(), "secret-value") {
+		t.Errorf("expected a line-numbered error without the value, got %v", err)
+	}
+}
+
+func TestFetcherCookieJar(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var names []string
+		for _, cookie := range r.Cookies() {
+			names = append(names, cookie.Name+"="+cookie.Value)
+		}
+		received = append(received, strings.Join(names, ";"))
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "fresh", Path: "/"})
+		}
+	}))
+	defer server.Close()
+
+	// Start the jar from a cookies file for the test server's host
+	host := strings.TrimPrefix(server.URL, "http://")
+	hostname := host[:strings.LastIndex(host, ":")]
+	cookiesFile := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := os.WriteFile(cookiesFile, []byte(hostname+"\tFALSE\t/\tFALSE\t0\tpreset\tfromfile\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fetcher, err := newHTTPFetcher(FetchOptions{CookieFile: cookiesFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/login", "/page"} {
+		req, _ := fetcher.newRequest("GET", server.URL+path)
+		resp, err := fetcher.do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	expected := []string{"preset=fromfile", "preset=fromfile;session=fresh"}
+	if strings.Join(received, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected cookies %v, got %v", expected, received)
+	}
+}
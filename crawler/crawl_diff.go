@@ -0,0 +1,107 @@
+package crawler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Page change kinds between two crawls
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// diffColumns are the report columns compared between crawls
+var diffColumns = []string{
+	"status_code", "redirected_to", "title", "meta_description", "h1",
+	"canonical_url", "robots_directives", "word_count", "content_hash", "depth", "inbound_links",
+}
+
+// PageChange is one difference between two crawls. Added and removed
+// pages have no field; changed pages get one entry per changed field.
+type PageChange struct {
+	Change string
+	URL    string
+	Field  string
+	Old    string
+	New    string
+}
+
+// DiffSnapshots compares two crawls page by page, sorted by URL then
+// field. Custom fields from either crawl are compared after diffColumns.
+// It fails when a snapshot's fields can't be turned into columns.
+func DiffSnapshots(oldSnapshot, newSnapshot Snapshot) ([]PageChange, error) {
+	fields := mergeFieldNames(oldSnapshot.Fields, newSnapshot.Fields)
+	names := append(append([]string{}, diffColumns...), fields...)
+	columns, err := lookupColumns(names, fields)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't compare crawls: %w", err)
+	}
+	value := func(col reportColumn, pageData PageData) string {
+		return strings.Join(col.values(pageData), ";")
+	}
+
+	var changes []PageChange
+	for key, oldPage := range oldSnapshot.Pages {
+		newPage, exists := newSnapshot.Pages[key]
+		if !exists {
+			changes = append(changes, PageChange{Change: ChangeRemoved, URL: oldPage.URL})
+			continue
+		}
+		for _, col := range columns {
+			if before, after := value(col, oldPage), value(col, newPage); before != after {
+				changes = append(changes, PageChange{Change: ChangeChanged, URL: newPage.URL, Field: col.Name, Old: before, New: after})
+			}
+		}
+	}
+	for key, newPage := range newSnapshot.Pages {
+		if _, exists := oldSnapshot.Pages[key]; !exists {
+			changes = append(changes, PageChange{Change: ChangeAdded, URL: newPage.URL})
+		}
+	}
+
+	// Order fields as in diffColumns, then the custom fields
+	fieldOrder := make(map[string]int, len(names))
+	for i, name := range names {
+		fieldOrder[name] = i
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].URL != changes[j].URL {
+			return changes[i].URL < changes[j].URL
+		}
+		return fieldOrder[changes[i].Field] < fieldOrder[changes[j].Field]
+	})
+
+	return changes, nil
+}
+
+// mergeFieldNames returns a's fields followed by those only b has
+func mergeFieldNames(a, b []string) []string {
+	seen := make(map[string]bool, len(a))
+	merged := append([]string{}, a...)
+	for _, name := range a {
+		seen[name] = true
+	}
+	for _, name := range b {
+		if !seen[name] {
+			seen[name] = true
+			merged = append(merged, name)
+		}
+	}
+	return merged
+}
+
+// diffHeader is the column layout of the diff report
+var diffHeader = []string{"change", "page_url", "field", "old_value", "new_value"}
+
+// WriteDiffCSV writes one row per change
+func WriteDiffCSV(changes []PageChange, filename string, opts CSVOptions) error {
+	rows := make([][]string, len(changes))
+	for i, change := range changes {
+		rows[i] = []string{change.Change, change.URL, change.Field, change.Old, change.New}
+	}
+
+	return writeCSVRows(filename, opts, diffHeader, rows)
+}
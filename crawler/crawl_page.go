@@ -0,0 +1,100 @@
+package crawler
+
+import "net/url"
+
+// crawlPage recursively crawls pages starting from rawCurrentURL.
+// depth is the number of links followed from the base URL to get here.
+func (cfg *config) crawlPage(rawCurrentURL string, depth int) {
+	// Stop fetching once the crawl is cancelled
+	if cfg.ctx.Err() != nil {
+		return
+	}
+
+	// Check if we've reached max pages limit (thread-safe check)
+	cfg.mu.Lock()
+	if len(cfg.pages) >= cfg.maxPages {
+		cfg.mu.Unlock()
+		return
+	}
+	cfg.mu.Unlock()
+
+	// Parse current URL
+	currentURL, err := url.Parse(rawCurrentURL)
+	if err != nil {
+		cfg.logf("Error parsing current URL: %v\n", err)
+		return
+	}
+
+	// Check if current URL is on the same domain as base URL
+	if currentURL.Host != cfg.baseURL.Host {
+		// External link - don't crawl
+		return
+	}
+
+	// Normalize the current URL
+	normalizedURL, err := cfg.normalizer.normalize(rawCurrentURL)
+	if err != nil {
+		cfg.logf("Error normalizing URL %s: %v\n", rawCurrentURL, err)
+		return
+	}
+
+	// Fetch the current URL
+	result, err := cfg.fetcher.Fetch(cfg.ctx, rawCurrentURL)
+	if err != nil {
+		cfg.logf("Error fetching %s: %v\n", rawCurrentURL, err)
+		return
+	}
+
+	var pageData PageData
+	switch {
+	case result.StatusCode >= 400:
+		// Record broken pages so they show up in the report, but don't extract anything
+		cfg.logf("Error fetching %s: HTTP error: status code %d\n", rawCurrentURL, result.StatusCode)
+		pageData = PageData{URL: rawCurrentURL}
+	case !result.isHTML():
+		cfg.logf("Error fetching %s: invalid content type: %s, expected text/html\n", rawCurrentURL, result.ContentType)
+		return
+	default:
+		// Extract page data
+		pageData, err = extractPage(result, rawCurrentURL, cfg.extractors)
+		if err != nil {
+			cfg.logf("Error extracting fields from %s: %v\n", rawCurrentURL, err)
+		}
+	}
+	pageData.StatusCode = result.StatusCode
+	pageData.Depth = depth
+	if result.FinalURL != "" && result.FinalURL != rawCurrentURL {
+		pageData.RedirectedTo = result.FinalURL
+	}
+	pageData.Robots.addRobotsHeader(result.Header.Values("X-Robots-Tag"))
+	pageData.SecurityHeaders = securityHeadersFrom(result.Header)
+
+	// Check if this is the first visit to this page
+	isFirst := cfg.addPageVisit(normalizedURL, pageData)
+	if !isFirst {
+		// Already visited - don't crawl again
+		return
+	}
+
+	// Print progress
+	cfg.logf("Crawling: %s\n", rawCurrentURL)
+
+	// Stream the page to the report writers right away
+	if !cfg.shouldReport(pageData) {
+		cfg.noindexSkipped.Add(1)
+	} else if cfg.sink != nil {
+		cfg.sink.send(pageData)
+	}
+
+	// Recursively crawl each URL found on the page (CONCURRENTLY!)
+	for _, nextURL := range cfg.linksToFollow(pageData) {
+		cfg.wg.Add(1)
+		go func(url string) {
+			defer cfg.wg.Done()
+			defer func() { <-cfg.concurrencyControl }() // Release semaphore
+
+			cfg.concurrencyControl <- struct{}{} // Acquire semaphore
+			cfg.crawlPage(url, depth+1)
+		}(nextURL)
+	}
+}
@@ -0,0 +1,120 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCrawlPageWithMemoryFetcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		site     MemoryFetcher
+		maxPages int
+		expected map[string]int // Page key to depth
+		statuses map[string]int // Page key to status code, when not 200
+	}{
+		{
+			name: "loops back to visited pages",
+			site: MemoryFetcher{
+				"https://example.com":   {Body: `<a href="/a">A</a><a href="/b">B</a>`},
+				"https://example.com/a": {Body: `<a href="/c">C</a><a href="/">Home</a>`},
+				"https://example.com/b": {Body: `<a href="/">Home</a><a href="/b">Self</a>`},
+				"https://example.com/c": {Body: `<a href="/a">A</a><a href="https://example.com">Home</a>`},
+			},
+			expected: map[string]int{"example.com": 0, "example.com/a": 1, "example.com/b": 1, "example.com/c": 2},
+		},
+		{
+			name: "stops at the page limit",
+			site: MemoryFetcher{
+				"https://example.com":   {Body: `<a href="/1">1</a>`},
+				"https://example.com/1": {Body: `<a href="/2">2</a>`},
+				"https://example.com/2": {Body: `<a href="/3">3</a>`},
+				"https://example.com/3": {Body: `<a href="/4">4</a>`},
+			},
+			maxPages: 3,
+			expected: map[string]int{"example.com": 0, "example.com/1": 1, "example.com/2": 2},
+		},
+		{
+			name: "skips external and non-HTML pages, records broken ones",
+			site: MemoryFetcher{
+				"https://example.com": {Body: `<a href="https://other.example/">Other</a>
+					<a href="/report">Report</a><a href="/missing">Missing</a><a href="/old">Old</a>`},
+				"https://example.com/report": {ContentType: "application/json", Body: `{}`},
+				"https://example.com/old":    {FinalURL: "https://example.com/new", Body: `<h1>New</h1>`},
+			},
+			expected: map[string]int{"example.com": 0, "example.com/missing": 1, "example.com/old": 1},
+			statuses: map[string]int{"example.com/missing": http.StatusNotFound},
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := New(Options{URL: "https://example.com", MaxConcurrency: 1, MaxPages: tc.maxPages, Fetcher: tc.site})
+			if err != nil {
+				t.Fatal(err)
+			}
+			snapshot, err := c.Run(context.Background())
+			if err != nil {
+				t.Fatalf("Test %v - %s FAIL: unexpected error: %v", i, tc.name, err)
+			}
+
+			depths := make(map[string]int)
+			for key, page := range snapshot.Pages {
+				depths[key] = page.Depth
+				expectedStatus := http.StatusOK
+				if status, ok := tc.statuses[key]; ok {
+					expectedStatus = status
+				}
+				if page.StatusCode != expectedStatus {
+					t.Errorf("Test %v - %s FAIL: expected %s to have status %d, got %d", i, tc.name, key, expectedStatus, page.StatusCode)
+				}
+			}
+			if !reflect.DeepEqual(depths, tc.expected) {
+				t.Errorf("Test %v - %s FAIL: expected pages %v, got %v", i, tc.name, tc.expected, depths)
+			}
+		})
+	}
+}
+
+func TestCrawlPageRedirect(t *testing.T) {
+	site := MemoryFetcher{
+		"https://example.com": {FinalURL: "https://example.com/home", Body: `<h1>Home</h1>`},
+	}
+	c, err := New(Options{URL: "https://example.com", Fetcher: site})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page := snapshot.Pages["example.com"]
+	if page.RedirectedTo != "https://example.com/home" || page.H1 != "Home" {
+		t.Errorf("expected the redirect to be recorded, got %+v", page)
+	}
+}
+
+func TestCrawlPageResolvesLinksAgainstRedirectTarget(t *testing.T) {
+	site := MemoryFetcher{
+		"https://example.com": {Body: `<a href="/a">A</a>`},
+		// /a redirects to /dir/, which the fetcher followed
+		"https://example.com/a": {FinalURL: "https://example.com/dir/", Body: `<a href="page">Page</a>`},
+	}
+	c, err := New(Options{URL: "https://example.com", Fetcher: site})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The relative link resolves against /dir/, where the redirect ended
+	page := snapshot.Pages["example.com/a"]
+	if expected := []string{"https://example.com/dir/page"}; !reflect.DeepEqual(page.OutgoingLinks, expected) {
+		t.Errorf("expected links %v, got %v", expected, page.OutgoingLinks)
+	}
+}
@@ -0,0 +1,241 @@
+// Package crawler crawls a site from a base URL, extracting the data the
+// link, SEO and content reports need from every page it finds.
+//
+// A crawl starts from New and Run:
+//
+//	c, err := crawler.New(crawler.Options{URL: "https://example.com", MaxPages: 50})
+//	if err != nil {
+//		return err
+//	}
+//	snapshot, err := c.Run(ctx)
+//
+// Run returns a Snapshot holding every crawled page, which WriteReports
+// turns into the CSV reports and SaveSnapshot stores for later.
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Default limits used when Options leaves them at zero
+const (
+	DefaultMaxConcurrency = 5
+	DefaultMaxPages       = 100
+)
+
+// Options configures a Crawler
+type Options struct {
+	URL            string       // Base URL; only pages on its host are crawled
+	MaxConcurrency int          // Pages fetched at once; zero means DefaultMaxConcurrency
+	MaxPages       int          // Stop after this many pages; zero means DefaultMaxPages
+	Include        []string     // Only crawl links matching one of these patterns
+	Exclude        []string     // Never crawl links matching any of these patterns
+	Robots         RobotsPolicy // Skip nofollow links and pages when respecting robots directives
+	Fetch          FetchOptions // Headers, credentials, cookies, timeout, rate limit and login
+
+	// Fetcher gets pages and the sitemap. Nil means an HTTP fetcher built
+	// from Fetch, which is then ignored.
+	Fetcher Fetcher
+
+	// Extractors set custom fields on every HTML page, after the ones
+	// added with RegisterExtractor
+	Extractors []Extractor
+
+	// Normalize configures how URLs are turned into page keys. It is saved
+	// in the snapshot, so reports resolve links to the same keys.
+	Normalize NormalizeOptions
+
+	// OnPage is called with each page as soon as it's crawled, one page at
+	// a time. Noindex pages are skipped when respecting robots directives.
+	// The crawl goes on after an error; Run returns the first one.
+	OnPage func(pageData PageData) error
+
+	// DiscardPages keeps memory bounded on large crawls: pages only reach
+	// OnPage, and the snapshot keeps just their URLs, so it can't be used
+	// for WriteReports or SaveSnapshot. Without it, every PageData stays in
+	// memory until Run returns.
+	DiscardPages bool
+
+	// Log receives progress and per-page errors; nil discards them
+	Log io.Writer
+}
+
+// Crawler crawls one site. Create it with New.
+type Crawler struct {
+	opts       Options
+	baseURL    *url.URL
+	scope      urlScope
+	fetcher    Fetcher
+	extractors []Extractor    // Registered extractors, then Options.Extractors
+	normalizer *urlNormalizer // Built from Options.Normalize
+}
+
+// New validates the options and prepares a crawler. Without a Fetcher,
+// it reads the cookies file and credentials for the HTTP fetcher.
+func New(opts Options) (*Crawler, error) {
+	if err := ValidateURL(opts.URL); err != nil {
+		return nil, err
+	}
+	baseURL, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse URL: %w", err)
+	}
+
+	// Fill in the defaults
+	if opts.MaxConcurrency == 0 {
+		opts.MaxConcurrency = DefaultMaxConcurrency
+	}
+	if opts.MaxPages == 0 {
+		opts.MaxPages = DefaultMaxPages
+	}
+	if opts.MaxConcurrency < 0 {
+		return nil, fmt.Errorf("maxConcurrency must be at least 1")
+	}
+	if opts.MaxPages < 0 {
+		return nil, fmt.Errorf("maxPages must be at least 1")
+	}
+	if opts.Log == nil {
+		opts.Log = io.Discard
+	}
+
+	scope, err := newURLScope(opts.Include, opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	extractors := append(registeredExtractors(), opts.Extractors...)
+	if err := checkExtractors(extractors); err != nil {
+		return nil, err
+	}
+	fetcher := opts.Fetcher
+	if fetcher == nil {
+		if fetcher, err = newHTTPFetcher(opts.Fetch); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Crawler{
+		opts:       opts,
+		baseURL:    baseURL,
+		scope:      scope,
+		fetcher:    fetcher,
+		extractors: extractors,
+		normalizer: newURLNormalizer(opts.Normalize),
+	}, nil
+}
+
+// Run logs in if the fetcher has a login form, crawls the site and reads
+// its sitemap. When ctx is cancelled, Run stops fetching and returns the
+// pages crawled so far along with ctx's error.
+func (c *Crawler) Run(ctx context.Context) (Snapshot, error) {
+	if fetcher, ok := c.fetcher.(loginFetcher); ok {
+		if err := fetcher.logIn(c.opts.Log); err != nil {
+			return Snapshot{}, err
+		}
+	}
+
+	// Pass pages to OnPage from a single goroutine
+	var sink *reportSink
+	if c.opts.OnPage != nil {
+		sink = newReportSink(c.opts.MaxConcurrency*2, pageFunc(c.opts.OnPage))
+	}
+
+	// Configure the crawl
+	cfg := &config{
+		pages:              make(map[string]PageData),
+		baseURL:            c.baseURL,
+		mu:                 &sync.Mutex{},
+		concurrencyControl: make(chan struct{}, c.opts.MaxConcurrency),
+		wg:                 &sync.WaitGroup{},
+		maxPages:           c.opts.MaxPages,
+		sink:               sink,
+		retainPages:        !c.opts.DiscardPages,
+		robotsPolicy:       c.opts.Robots,
+		scope:              c.scope,
+		ctx:                ctx,
+		fetcher:            c.fetcher,
+		extractors:         c.extractors,
+		log:                c.opts.Log,
+		normalizer:         c.normalizer,
+	}
+
+	// Start the first crawl
+	cfg.wg.Add(1)
+	go func() {
+		defer cfg.wg.Done()
+		cfg.concurrencyControl <- struct{}{}        // Acquire semaphore
+		defer func() { <-cfg.concurrencyControl }() // Release semaphore
+
+		cfg.crawlPage(c.opts.URL, 0)
+	}()
+
+	// Wait for all goroutines to finish
+	cfg.wg.Wait()
+	cfg.logRobotsSkips()
+
+	snapshot := Snapshot{
+		BaseURL:   c.opts.URL,
+		CrawledAt: time.Now().UTC(),
+		Fields:    c.Fields(),
+		Normalize: c.opts.Normalize,
+		Pages:     cfg.pages,
+	}
+
+	// Wait for the last pages to reach OnPage
+	var pageErr error
+	if sink != nil {
+		pageErr = sink.close()
+	}
+	if err := ctx.Err(); err != nil {
+		return snapshot, err
+	}
+	if pageErr != nil {
+		return snapshot, pageErr
+	}
+
+	// A missing sitemap only disables the sitemap checks
+	sitemapURLs, err := fetchSitemapURLs(ctx, c.fetcher, defaultSitemapURL(c.baseURL))
+	if err != nil {
+		fmt.Fprintf(c.opts.Log, "warning: couldn't read sitemap, skipping sitemap checks: %v\n", err)
+	}
+	snapshot.SitemapURLs = sitemapURLs
+
+	return snapshot, nil
+}
+
+// Fields lists the custom fields the crawler's extractors declare, in
+// column order. Pass them in CSVOptions.Fields to stream them into a
+// report while crawling.
+func (c *Crawler) Fields() []string {
+	return extractorFields(c.extractors)
+}
+
+// WriteReports writes every report like the package-level WriteReports.
+// Images are probed through the crawler's fetcher, so probes share its
+// headers, credentials, cookies and rate limit, and replayed crawls stay
+// offline. Fetchers that aren't HeadFetchers can't probe, so probing is
+// turned off with a notice on out. opts.Fetch is ignored.
+func (c *Crawler) WriteReports(snapshot Snapshot, opts ReportOptions, out io.Writer) error {
+	fetcher, ok := headFetcher(c.fetcher)
+	if !ok && opts.ProbeImages {
+		fmt.Fprintln(out, "notice: the crawl's fetcher can't send HEAD requests, so images won't be probed")
+		opts.ProbeImages = false
+	}
+	return writeReports(snapshot, opts, out, fetcher)
+}
+
+// ValidateURL checks that a crawl can start from rawURL
+func ValidateURL(rawURL string) error {
+	baseURL, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("couldn't parse URL: %w", err)
+	}
+	if (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+		return fmt.Errorf("URL must be absolute and start with http:// or https://, got %q", rawURL)
+	}
+	return nil
+}
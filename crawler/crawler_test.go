@@ -0,0 +1,257 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newChainSite serves /0 through /n-1, each page linking to the next
+func newChainSite(t *testing.T, n int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var i int
+		if _, err := fmt.Sscanf(r.URL.Path, "/%d", &i); err != nil || i >= n {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><h1>Page %d</h1><a href="/%d">Next</a></body></html>`, i, i+1)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCrawlerRun(t *testing.T) {
+	server := newChainSite(t, 10)
+
+	var seen []string
+	var log bytes.Buffer
+	c, err := New(Options{
+		URL:      server.URL + "/0",
+		MaxPages: 4,
+		OnPage: func(pageData PageData) error {
+			seen = append(seen, pageData.URL)
+			return nil
+		},
+		Log: &log,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(snapshot.Pages) != 4 {
+		t.Errorf("expected 4 pages, got %d", len(snapshot.Pages))
+	}
+	sort.Strings(seen)
+	expected := []string{server.URL + "/0", server.URL + "/1", server.URL + "/2", server.URL + "/3"}
+	if strings.Join(seen, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected OnPage to see %v, got %v", expected, seen)
+	}
+	if !strings.Contains(log.String(), "Crawling: "+server.URL+"/0") {
+		t.Errorf("expected progress in the log, got %q", log.String())
+	}
+}
+
+func TestCrawlerRunDiscardPages(t *testing.T) {
+	server := newChainSite(t, 10)
+
+	var h1s []string
+	c, err := New(Options{
+		URL:          server.URL + "/0",
+		MaxPages:     3,
+		DiscardPages: true,
+		OnPage: func(pageData PageData) error {
+			h1s = append(h1s, pageData.H1)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// OnPage sees whole pages, the snapshot only their URLs
+	sort.Strings(h1s)
+	if strings.Join(h1s, ",") != "Page 0,Page 1,Page 2" {
+		t.Errorf("expected OnPage to see every page, got %v", h1s)
+	}
+	if len(snapshot.Pages) != 3 {
+		t.Errorf("expected 3 pages, got %d", len(snapshot.Pages))
+	}
+	for key, pageData := range snapshot.Pages {
+		if pageData.URL == "" || pageData.H1 != "" || len(pageData.OutgoingLinks) > 0 {
+			t.Errorf("expected only the URL of %s to be kept, got %+v", key, pageData)
+		}
+	}
+}
+
+func TestCrawlersKeepTheirOwnNormalization(t *testing.T) {
+	site := MemoryFetcher{
+		"https://example.com/":   {Body: `<html><body><a href="/a/">A</a> <a href="/a">A again</a></body></html>`},
+		"https://example.com/a/": {Body: `<html><body><a href="/">Home</a></body></html>`},
+		"https://example.com/a":  {Body: `<html><body><a href="/">Home</a></body></html>`},
+	}
+
+	// Run crawlers with different trailing slash policies side by side
+	policies := []SlashPolicy{SlashStrip, SlashKeep, SlashStrip, SlashKeep}
+	snapshots := make([]Snapshot, len(policies))
+	var wg sync.WaitGroup
+	for i, policy := range policies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := New(Options{URL: "https://example.com/", Fetcher: site, Normalize: NormalizeOptions{TrailingSlash: policy}})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			snapshots[i], _ = c.Run(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	for i, policy := range policies {
+		var keys []string
+		for key := range snapshots[i].Pages {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		expected := "example.com example.com/a"
+		if policy == SlashKeep {
+			expected = "example.com/ example.com/a example.com/a/"
+		}
+		if strings.Join(keys, " ") != expected {
+			t.Errorf("Test %v - %s FAIL: expected keys %q, got %q", i, policy, expected, strings.Join(keys, " "))
+		}
+		if snapshots[i].Normalize.TrailingSlash != policy {
+			t.Errorf("Test %v - %s FAIL: expected the snapshot to record the policy, got %+v", i, policy, snapshots[i].Normalize)
+		}
+	}
+}
+
+func TestCrawlerRunErrors(t *testing.T) {
+	server := newChainSite(t, 3)
+
+	// OnPage errors don't stop the crawl, but Run reports the first one
+	failing := errors.New("disk full")
+	c, err := New(Options{URL: server.URL + "/0", OnPage: func(PageData) error { return failing }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := c.Run(context.Background())
+	if !errors.Is(err, failing) {
+		t.Errorf("expected the OnPage error, got %v", err)
+	}
+	if len(snapshot.Pages) != 4 {
+		t.Errorf("expected 3 pages and the broken link, got %d", len(snapshot.Pages))
+	}
+
+	// A cancelled crawl returns what it has and the context's error
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c, err = New(Options{URL: server.URL + "/0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err = c.Run(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if len(snapshot.Pages) != 0 {
+		t.Errorf("expected no pages, got %d", len(snapshot.Pages))
+	}
+
+	// Options are checked up front
+	for _, opts := range []Options{{URL: "example.com"}, {URL: server.URL, MaxPages: -1}, {URL: server.URL, Include: []string{"("}}} {
+		if _, err := New(opts); err == nil {
+			t.Errorf("expected %+v to be rejected", opts)
+		}
+	}
+}
+
+func TestCrawlerRunWithFormLogin(t *testing.T) {
+	site := newLoginSite(t)
+	t.Setenv("LINKSCOUT_TEST_PASSWORD", "hunter2")
+
+	c, err := New(Options{
+		URL: site.URL + "/dashboard",
+		Fetch: FetchOptions{Login: LoginOptions{
+			URL:          site.URL + "/login",
+			Fields:       map[string]string{"username": "deploy"},
+			SecretFields: map[string]string{"password": "env:LINKSCOUT_TEST_PASSWORD"},
+			SuccessURL:   "/dashboard",
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(snapshot.Pages) != 3 {
+		t.Errorf("expected the 3 protected pages, got %d", len(snapshot.Pages))
+	}
+	for key, page := range snapshot.Pages {
+		if page.StatusCode != http.StatusOK || page.RedirectedTo != "" {
+			t.Errorf("expected %s to be crawled logged in, got status %d redirected to %q", key, page.StatusCode, page.RedirectedTo)
+		}
+	}
+}
+
+func TestCrawlerWriteReportsProbesThroughFetcher(t *testing.T) {
+	site := MemoryFetcher{
+		"https://example.com":          {Body: `<html><body><img src="/logo.png" alt="Logo"></body></html>`},
+		"https://example.com/logo.png": {ContentType: "image/png", Body: strings.Repeat("x", 300*1024)},
+	}
+
+	tests := []struct {
+		name    string
+		fetcher Fetcher
+		notice  bool
+	}{
+		{name: "HEAD-capable fetcher", fetcher: site},
+		{name: "GET-only fetcher", fetcher: getOnlyFetcher{site}, notice: true},
+	}
+	for i, tc := range tests {
+		c, err := New(Options{URL: "https://example.com", Fetcher: tc.fetcher})
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshot, err := c.Run(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out bytes.Buffer
+		dir := t.TempDir()
+		if err := c.WriteReports(snapshot, ReportOptions{OutputDir: dir, ProbeImages: true}, &out); err != nil {
+			t.Fatalf("Test %v - %s FAIL: unexpected error: %v", i, tc.name, err)
+		}
+		if notice := strings.Contains(out.String(), "won't be probed"); notice != tc.notice {
+			t.Errorf("Test %v - %s FAIL: expected notice %v, got output:\n%s", i, tc.name, tc.notice, out.String())
+		}
+		images, _ := os.ReadFile(filepath.Join(dir, "images.csv"))
+		if oversized := strings.Contains(string(images), "oversized"); oversized == tc.notice {
+			t.Errorf("Test %v - %s FAIL: expected the probe result only when probing, got:\n%s", i, tc.name, images)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// utf8BOM lets Excel detect that the file is UTF-8
+const utf8BOM = "\uFEFF"
+
+// CSVOptions controls the layout of a CSV report. Only the delimiter and
+// BOM options apply to reports other than report.csv.
+type CSVOptions struct {
+	Columns        []string // Column names in output order; empty means DefaultCSVColumns and Fields
+	Fields         []string // Custom fields set by extractors, available as columns
+	Delimiter      rune     // Field delimiter; zero means ','
+	MultiSeparator string   // Joins multi-valued fields; empty means ";"
+	LongFormat     bool     // Write one row per value of ExplodeColumn instead of joining
+	ExplodeColumn  string   // Multi-valued column split in long format; empty means outgoing_link_urls
+	BOM            bool     // Start the file with a UTF-8 byte order mark
+	SortBy         SortKey  // Row order for whole-crawl reports; empty means DefaultSortKey
+}
+
+// WithDefaults fills in any unset options
+func (opts CSVOptions) WithDefaults() CSVOptions {
+	if len(opts.Columns) == 0 {
+		opts.Columns = append(append([]string{}, DefaultCSVColumns...), opts.Fields...)
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+	if opts.MultiSeparator == "" {
+		opts.MultiSeparator = ";"
+	}
+	if opts.ExplodeColumn == "" {
+		opts.ExplodeColumn = "outgoing_link_urls"
+	}
+	if opts.SortBy == "" {
+		opts.SortBy = DefaultSortKey
+	}
+	return opts
+}
+
+// CSVPageWriter writes CSV rows per page and flushes after every page,
+// so partial results are on disk while the crawl is still running
+type CSVPageWriter struct {
+	file      *os.File
+	counter   *countingWriter // Tracks the file offset of every row
+	writer    *csv.Writer
+	opts      CSVOptions
+	columns   []reportColumn
+	explode   int       // Index into columns of the exploded column, or -1
+	headerEnd int64     // Offset of the first row
+	spans     []rowSpan // Where each page's rows are, for CloseSorted
+}
+
+// rowSpan is the sort key of a page and the byte range of its rows
+type rowSpan struct {
+	key        sortEntry
+	start, end int64
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// NewCSVPageWriter creates the CSV file and writes the header row
+func NewCSVPageWriter(filename string, opts CSVOptions) (*CSVPageWriter, error) {
+	opts = opts.WithDefaults()
+
+	columns, err := lookupColumns(opts.Columns, opts.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ValidCSVDelimiter(opts.Delimiter) {
+		return nil, fmt.Errorf("invalid CSV delimiter %q", opts.Delimiter)
+	}
+
+	explode := -1
+	if opts.LongFormat {
+		for i, col := range columns {
+			if col.Name == opts.ExplodeColumn {
+				explode = i
+			}
+		}
+		if explode == -1 || !columns[explode].Multi {
+			return nil, fmt.Errorf("long format needs multi-valued column %q in the column list", opts.ExplodeColumn)
+		}
+	}
+
+	// Write header row
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.Name
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create file: %w", err)
+	}
+	counter := &countingWriter{w: file}
+	writer, err := startCSV(counter, opts, header)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &CSVPageWriter{
+		file:      file,
+		counter:   counter,
+		writer:    writer,
+		opts:      opts,
+		columns:   columns,
+		explode:   explode,
+		headerEnd: counter.n,
+	}, nil
+}
+
+// createCSVFile creates filename, writes the optional BOM and the header row,
+// and returns a CSV writer using the configured delimiter
+func createCSVFile(filename string, opts CSVOptions, header []string) (*os.File, *csv.Writer, error) {
+	// Create the CSV file
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't create file: %w", err)
+	}
+
+	writer, err := startCSV(file, opts, header)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return file, writer, nil
+}
+
+// startCSV writes the optional BOM and the header row to w, and returns a
+// CSV writer using the configured delimiter
+func startCSV(w io.Writer, opts CSVOptions, header []string) (*csv.Writer, error) {
+	if opts.BOM {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return nil, fmt.Errorf("couldn't write BOM: %w", err)
+		}
+	}
+
+	// Create CSV writer
+	writer := csv.NewWriter(w)
+	writer.Comma = opts.Delimiter
+
+	if err := writer.Write(header); err != nil {
+		return nil, fmt.Errorf("couldn't write header: %w", err)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("couldn't write header: %w", err)
+	}
+
+	return writer, nil
+}
+
+// writeCSVRows writes a complete CSV file from a header and rows
+func writeCSVRows(filename string, opts CSVOptions, header []string, rows [][]string) error {
+	opts = opts.WithDefaults()
+	if !ValidCSVDelimiter(opts.Delimiter) {
+		return fmt.Errorf("invalid CSV delimiter %q", opts.Delimiter)
+	}
+
+	file, writer, err := createCSVFile(filename, opts, header)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("couldn't write row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error writing CSV: %w", err)
+	}
+
+	return file.Close()
+}
+
+// ValidCSVDelimiter mirrors the checks encoding/csv applies to Writer.Comma
+func ValidCSVDelimiter(r rune) bool {
+	return r != '"' && r != '\r' && r != '\n' && r != utf8.RuneError && utf8.ValidRune(r)
+}
+
+// WritePage writes a page as one CSV row, or one row per exploded value in long format
+func (w *CSVPageWriter) WritePage(pageData PageData) error {
+	// Collect every column's values, joining multi-valued ones
+	row := make([]string, len(w.columns))
+	var exploded []string
+	for i, col := range w.columns {
+		values := col.values(pageData)
+		if i == w.explode {
+			exploded = values
+			continue
+		}
+		row[i] = strings.Join(values, w.opts.MultiSeparator)
+	}
+
+	rows := [][]string{row}
+	if w.explode >= 0 && len(exploded) > 0 {
+		rows = make([][]string, len(exploded))
+		for i, value := range exploded {
+			rows[i] = append([]string(nil), row...)
+			rows[i][w.explode] = value
+		}
+	}
+
+	// Write rows to CSV
+	start := w.counter.n
+	for _, r := range rows {
+		if err := w.writer.Write(r); err != nil {
+			return fmt.Errorf("couldn't write row: %w", err)
+		}
+	}
+
+	// Flush so the rows are on disk even if the crawl is interrupted
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		return fmt.Errorf("error writing CSV: %w", err)
+	}
+
+	w.spans = append(w.spans, rowSpan{key: sortEntryFor(pageData), start: start, end: w.counter.n})
+	return nil
+}
+
+// CloseSorted closes the file like Close, then rewrites it with the pages
+// in the SortBy order. Each page's rows are copied back from the file by
+// offset, so only the sort keys were kept in memory. Sorting by inbound
+// links needs every page and isn't supported.
+func (w *CSVPageWriter) CloseSorted() error {
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if w.opts.SortBy == SortByInbound {
+		return fmt.Errorf("can't sort a streamed report by inbound links")
+	}
+
+	sort.SliceStable(w.spans, func(i, j int) bool {
+		return sortEntryLess(w.opts.SortBy, w.spans[i].key, w.spans[j].key)
+	})
+
+	src, err := os.Open(w.file.Name())
+	if err != nil {
+		return fmt.Errorf("couldn't reopen CSV report: %w", err)
+	}
+	defer src.Close()
+
+	// Write the sorted copy next to the report, then swap it in
+	dst, err := os.CreateTemp(filepath.Dir(w.file.Name()), ".report-*.csv")
+	if err != nil {
+		return fmt.Errorf("couldn't create sorted CSV report: %w", err)
+	}
+	defer os.Remove(dst.Name())
+
+	out := bufio.NewWriter(dst)
+	_, err = io.Copy(out, io.NewSectionReader(src, 0, w.headerEnd))
+	for _, span := range w.spans {
+		if err != nil {
+			break
+		}
+		_, err = io.Copy(out, io.NewSectionReader(src, span.start, span.end-span.start))
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't write sorted CSV report: %w", err)
+	}
+
+	if err := os.Rename(dst.Name(), w.file.Name()); err != nil {
+		return fmt.Errorf("couldn't replace CSV report: %w", err)
+	}
+	return nil
+}
+
+// Close flushes any buffered rows and closes the file
+func (w *CSVPageWriter) Close() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("error writing CSV: %w", err)
+	}
+
+	return w.file.Close()
+}
+
+// writeCSVReport writes the crawl results to a CSV file
+func writeCSVReport(pages map[string]PageData, filename string, opts CSVOptions) error {
+	opts = opts.WithDefaults()
+
+	writer, err := NewCSVPageWriter(filename, opts)
+	if err != nil {
+		return err
+	}
+
+	// Write data rows in a stable order so reports diff cleanly
+	for _, pageData := range sortPages(pages, opts.SortBy) {
+		if err := writer.WritePage(pageData); err != nil {
+			writer.Close()
+			return err
+		}
+	}
+
+	return writer.Close()
+}
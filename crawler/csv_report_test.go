@@ -0,0 +1,310 @@
+package crawler
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testReportPages() map[string]PageData {
+	return map[string]PageData{
+		"example.com": {
+			URL:           "https://example.com",
+			H1:            "Home",
+			OutgoingLinks: []string{"https://example.com/b", "https://example.com/a"},
+			Depth:         0,
+			StatusCode:    200,
+		},
+		"example.com/a": {
+			URL:           "https://example.com/a",
+			H1:            "A",
+			OutgoingLinks: []string{"https://example.com/b"},
+			Depth:         1,
+			StatusCode:    200,
+		},
+		"example.com/b": {
+			URL:        "https://example.com/b",
+			Depth:      1,
+			StatusCode: 404,
+		},
+		"example.com/c": {
+			URL:        "https://example.com/c",
+			H1:         "C",
+			Depth:      2,
+			StatusCode: 200,
+		},
+	}
+}
+
+func TestWriteCSVReportIsByteIdentical(t *testing.T) {
+	dir := t.TempDir()
+
+	// Write the same pages several times; map iteration order differs each run
+	var previous []byte
+	for i := 0; i < 5; i++ {
+		filename := filepath.Join(dir, "report.csv")
+		if err := writeCSVReport(testReportPages(), filename, CSVOptions{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		actual, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("couldn't read report: %v", err)
+		}
+
+		if previous != nil && !bytes.Equal(previous, actual) {
+			t.Fatalf("run %d differs:\n%s\nvs\n%s", i, previous, actual)
+		}
+		previous = actual
+	}
+}
+
+func TestSortPages(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      SortKey
+		expected []string
+	}{
+		{
+			name: "by url",
+			key:  SortByURL,
+			expected: []string{
+				"https://example.com",
+				"https://example.com/a",
+				"https://example.com/b",
+				"https://example.com/c",
+			},
+		},
+		{
+			name: "by depth",
+			key:  SortByDepth,
+			expected: []string{
+				"https://example.com",
+				"https://example.com/a",
+				"https://example.com/b",
+				"https://example.com/c",
+			},
+		},
+		{
+			name: "by inbound links",
+			key:  SortByInbound,
+			expected: []string{
+				"https://example.com/b",
+				"https://example.com/a",
+				"https://example.com",
+				"https://example.com/c",
+			},
+		},
+		{
+			name: "by status",
+			key:  SortByStatus,
+			expected: []string{
+				"https://example.com",
+				"https://example.com/a",
+				"https://example.com/c",
+				"https://example.com/b",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Inbound counts come from the link graph, as in writeReports
+			pages := testReportPages()
+			opts := graphOptions{DropSelfLoops: true}
+			applyInboundCounts(pages, buildLinkGraph(pages, opts), opts)
+
+			var actual []string
+			for _, pageData := range sortPages(pages, tc.key) {
+				actual = append(actual, pageData.URL)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseSortKey(t *testing.T) {
+	if key, err := ParseSortKey(""); err != nil || key != DefaultSortKey {
+		t.Errorf("expected default key, got %q (%v)", key, err)
+	}
+	if key, err := ParseSortKey("inbound"); err != nil || key != SortByInbound {
+		t.Errorf("expected inbound, got %q (%v)", key, err)
+	}
+	if _, err := ParseSortKey("size"); err == nil {
+		t.Errorf("expected error for unknown key")
+	}
+}
+
+func TestWriteCSVReportOptions(t *testing.T) {
+	pages := map[string]PageData{
+		"example.com": {
+			URL:           "https://example.com",
+			H1:            "Home",
+			OutgoingLinks: []string{"https://example.com/a;b", "https://example.com/c"},
+			StatusCode:    200,
+		},
+		"example.com/d": {
+			URL:        "https://example.com/d",
+			StatusCode: 404,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		opts     CSVOptions
+		expected string
+	}{
+		{
+			name: "column selection and order",
+			opts: CSVOptions{Columns: []string{"status_code", "page_url"}},
+			expected: "status_code,page_url\n" +
+				"200,https://example.com\n" +
+				"404,https://example.com/d\n",
+		},
+		{
+			name: "delimiter and multi-value separator",
+			opts: CSVOptions{
+				Columns:        []string{"page_url", "outgoing_link_urls"},
+				Delimiter:      '\t',
+				MultiSeparator: " | ",
+			},
+			expected: "page_url\toutgoing_link_urls\n" +
+				"https://example.com\thttps://example.com/a;b | https://example.com/c\n" +
+				"https://example.com/d\t\n",
+		},
+		{
+			name: "long format",
+			opts: CSVOptions{
+				Columns:    []string{"page_url", "outgoing_link_urls"},
+				LongFormat: true,
+			},
+			expected: "page_url,outgoing_link_urls\n" +
+				"https://example.com,https://example.com/a;b\n" +
+				"https://example.com,https://example.com/c\n" +
+				"https://example.com/d,\n",
+		},
+		{
+			name:     "byte order mark",
+			opts:     CSVOptions{Columns: []string{"h1"}, BOM: true},
+			expected: utf8BOM + "h1\nHome\n\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "report.csv")
+			if err := writeCSVReport(pages, filename, tc.opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			actual, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("couldn't read report: %v", err)
+			}
+			if string(actual) != tc.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", tc.expected, string(actual))
+			}
+		})
+	}
+}
+
+func TestCSVPageWriterCloseSorted(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     CSVOptions
+		expected string
+	}{
+		{
+			name: "by URL, with a BOM",
+			opts: CSVOptions{Columns: []string{"page_url", "depth"}, BOM: true},
+			expected: utf8BOM + "page_url,depth\n" +
+				"https://example.com,0\n" +
+				"https://example.com/a,1\n" +
+				"https://example.com/b,1\n" +
+				"https://example.com/c,2\n",
+		},
+		{
+			name: "by status, in long format",
+			opts: CSVOptions{Columns: []string{"status_code", "page_url", "outgoing_link_urls"}, LongFormat: true, SortBy: SortByStatus},
+			expected: "status_code,page_url,outgoing_link_urls\n" +
+				"200,https://example.com,https://example.com/b\n" +
+				"200,https://example.com,https://example.com/a\n" +
+				"200,https://example.com/a,https://example.com/b\n" +
+				"200,https://example.com/c,\n" +
+				"404,https://example.com/b,\n",
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "report.csv")
+			writer, err := NewCSVPageWriter(filename, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Write in crawl order, which is anything but sorted
+			pages := testReportPages()
+			for _, key := range []string{"example.com/c", "example.com/b", "example.com", "example.com/a"} {
+				if err := writer.WritePage(pages[key]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := writer.CloseSorted(); err != nil {
+				t.Fatalf("Test %v - %s FAIL: unexpected error: %v", i, tc.name, err)
+			}
+
+			actual, _ := os.ReadFile(filename)
+			if string(actual) != tc.expected {
+				t.Errorf("Test %v - %s FAIL: expected:\n%q\ngot:\n%q", i, tc.name, tc.expected, string(actual))
+			}
+			if leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(filename), ".report-*")); len(leftovers) > 0 {
+				t.Errorf("Test %v - %s FAIL: temporary files left behind: %v", i, tc.name, leftovers)
+			}
+		})
+	}
+
+	writer, err := NewCSVPageWriter(filepath.Join(t.TempDir(), "report.csv"), CSVOptions{SortBy: SortByInbound})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.CloseSorted(); err == nil {
+		t.Error("expected sorting a streamed report by inbound links to fail")
+	}
+}
+
+func TestWriteCSVReportInvalidOptions(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "report.csv")
+
+	invalid := []CSVOptions{
+		{Columns: []string{"no_such_column"}},
+		{Delimiter: '"'},
+		{Columns: []string{"page_url"}, LongFormat: true},
+		{Columns: []string{"page_url", "h1"}, LongFormat: true, ExplodeColumn: "h1"},
+	}
+
+	for _, opts := range invalid {
+		if err := writeCSVReport(testReportPages(), filename, opts); err == nil {
+			t.Errorf("expected error for options %+v", opts)
+		}
+	}
+}
+
+func TestColumnRegistryCoversPageData(t *testing.T) {
+	// Every PageData field must declare a csv column (or opt out with "-")
+	pageType := reflect.TypeOf(PageData{})
+	for i := 0; i < pageType.NumField(); i++ {
+		field := pageType.Field(i)
+		if _, ok := field.Tag.Lookup("csv"); !ok {
+			t.Errorf("PageData.%s has no csv tag", field.Name)
+		}
+	}
+
+	if _, err := lookupColumns(DefaultCSVColumns, nil); err != nil {
+		t.Errorf("default columns must be registered: %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package crawler
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Duplicate cluster kinds
+const (
+	duplicateExact = "exact" // Identical visible text
+	duplicateNear  = "near"  // SimHash similarity at or above the threshold
+)
+
+// duplicateOptions tunes duplicate detection
+type duplicateOptions struct {
+	SimilarityThreshold float64 // Minimum SimHash similarity for near duplicates; zero means 0.9
+	MinWords            int     // Pages with fewer words are skipped for near duplicates; zero means 20
+}
+
+// duplicateCluster is a group of pages with the same or nearly the same content
+type duplicateCluster struct {
+	ID    int
+	Kind  string
+	Pages []PageData // Sorted by URL
+}
+
+// findDuplicates groups pages with identical content, then pages whose
+// content is nearly identical. Near clusters are only reported when they
+// contain more than one distinct content hash, so exact duplicates don't
+// show up twice.
+func findDuplicates(pages map[string]PageData, opts duplicateOptions) []duplicateCluster {
+	if opts.SimilarityThreshold == 0 {
+		opts.SimilarityThreshold = 0.9
+	}
+	if opts.MinWords == 0 {
+		opts.MinWords = 20
+	}
+
+	var candidates []PageData
+	for _, pageData := range sortPages(pages, SortByURL) {
+		// Pages without text all share the hash of "", so they aren't duplicates
+		if pageData.ContentHash != "" && pageData.WordCount > 0 && pageData.StatusCode < 300 {
+			candidates = append(candidates, pageData)
+		}
+	}
+
+	var clusters []duplicateCluster
+
+	// Exact duplicates share a content hash
+	byHash := make(map[string][]PageData)
+	var hashes []string
+	for _, pageData := range candidates {
+		if _, seen := byHash[pageData.ContentHash]; !seen {
+			hashes = append(hashes, pageData.ContentHash)
+		}
+		byHash[pageData.ContentHash] = append(byHash[pageData.ContentHash], pageData)
+	}
+	for _, hash := range hashes {
+		if group := byHash[hash]; len(group) > 1 {
+			clusters = append(clusters, duplicateCluster{Kind: duplicateExact, Pages: group})
+		}
+	}
+
+	// Near duplicates: union every pair of similar pages, comparing only
+	// pages that share a SimHash band
+	var near []PageData
+	for _, pageData := range candidates {
+		if pageData.WordCount >= opts.MinWords {
+			near = append(near, pageData)
+		}
+	}
+
+	parent := make([]int, len(near))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for _, bucket := range simHashBuckets(near, opts.SimilarityThreshold) {
+		for a, i := range bucket {
+			for _, j := range bucket[a+1:] {
+				if find(i) == find(j) {
+					continue // Already in the same cluster
+				}
+				if near[i].SimHash.similarity(near[j].SimHash) >= opts.SimilarityThreshold {
+					parent[find(j)] = find(i)
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]PageData)
+	var roots []int
+	for i, pageData := range near {
+		root := find(i)
+		if _, seen := groups[root]; !seen {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], pageData)
+	}
+	sort.Ints(roots)
+	for _, root := range roots {
+		group := groups[root]
+		distinct := make(map[string]bool)
+		for _, pageData := range group {
+			distinct[pageData.ContentHash] = true
+		}
+		if len(distinct) > 1 {
+			clusters = append(clusters, duplicateCluster{Kind: duplicateNear, Pages: group})
+		}
+	}
+
+	for i := range clusters {
+		clusters[i].ID = i + 1
+	}
+	return clusters
+}
+
+// simHashBuckets groups page indexes that share at least one band of
+// their SimHash. Fingerprints within the threshold differ in at most k
+// bits, so splitting them into k+1 bands guarantees that such pairs match
+// exactly on one band and always end up in a common bucket. Buckets are
+// returned in a stable order, each sorted by index.
+func simHashBuckets(pages []PageData, threshold float64) [][]int {
+	maxDistance := int(math.Floor((1-threshold)*64 + 1e-9))
+	bands := min(maxDistance+1, 64)
+
+	type bandKey struct {
+		band  int
+		value uint64
+	}
+	buckets := make(map[bandKey][]int)
+	var keys []bandKey
+	for i, pageData := range pages {
+		for band := 0; band < bands; band++ {
+			// Band b covers bits [b*64/bands, (b+1)*64/bands); a 64-bit
+			// shift yields zero, so a single band masks every bit
+			low, high := band*64/bands, (band+1)*64/bands
+			mask := uint64(1)<<(high-low) - 1
+			key := bandKey{band: band, value: uint64(pageData.SimHash) >> low & mask}
+			if _, seen := buckets[key]; !seen {
+				keys = append(keys, key)
+			}
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+
+	var result [][]int
+	for _, key := range keys {
+		if bucket := buckets[key]; len(bucket) > 1 {
+			result = append(result, bucket)
+		}
+	}
+	return result
+}
+
+// duplicatesHeader is the column layout of the duplicates report
+var duplicatesHeader = []string{"cluster_id", "kind", "page_url", "canonical_url", "similarity", "content_hash", "simhash"}
+
+// writeDuplicatesCSV writes one row per page per cluster. similarity is
+// measured against the first page of the cluster.
+func writeDuplicatesCSV(clusters []duplicateCluster, filename string, opts CSVOptions) error {
+	var rows [][]string
+	for _, cluster := range clusters {
+		first := cluster.Pages[0]
+		for _, pageData := range cluster.Pages {
+			similarity := 1.0
+			if cluster.Kind == duplicateNear {
+				similarity = first.SimHash.similarity(pageData.SimHash)
+			}
+			rows = append(rows, []string{
+				strconv.Itoa(cluster.ID),
+				cluster.Kind,
+				pageData.URL,
+				pageData.Canonical,
+				strconv.FormatFloat(similarity, 'f', 3, 64),
+				pageData.ContentHash,
+				pageData.SimHash.String(),
+			})
+		}
+	}
+
+	return writeCSVRows(filename, opts, duplicatesHeader, rows)
+}
@@ -0,0 +1,127 @@
+package crawler
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// articleHTML wraps text in a page with boilerplate that must not affect hashing
+func articleHTML(text string) string {
+	return `<html><head><title>T</title><style>body { color: red }</style></head>
+<body><script>var tracking = "` + fmt.Sprint(len(text)) + `";</script><main><p>` + text + `</p></main></body></html>`
+}
+
+func TestContentFingerprints(t *testing.T) {
+	a := extractPageData(articleHTML("Hello,   World! This is Go."), "https://example.com/a")
+	b := extractPageData(articleHTML("hello world this is go"), "https://example.com/b")
+	c := extractPageData(articleHTML("Something else entirely."), "https://example.com/c")
+
+	if a.WordCount != 5 {
+		t.Errorf("expected 5 words, got %d", a.WordCount)
+	}
+	if a.ContentHash != b.ContentHash {
+		t.Errorf("expected whitespace, case and punctuation to be ignored")
+	}
+	if a.ContentHash == c.ContentHash {
+		t.Errorf("expected different content to hash differently")
+	}
+	if a.SimHash.similarity(b.SimHash) != 1 {
+		t.Errorf("expected identical SimHash for identical words")
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	base := strings.Repeat("the quick brown fox jumps over the lazy dog while the cat sleeps ", 10)
+	edited := strings.Replace(base, "lazy dog", "sleepy dog", 1)
+	other := strings.Repeat("completely unrelated words about gardening tomatoes and soil health ", 10)
+
+	page := func(path, text, canonical string) PageData {
+		pageData := extractPageData(articleHTML(text), "https://example.com"+path)
+		pageData.StatusCode = 200
+		pageData.Canonical = canonical
+		return pageData
+	}
+
+	pages := map[string]PageData{
+		"example.com/post":       page("/post", base, "https://example.com/post"),
+		"example.com/post-print": page("/post-print", base, "https://example.com/post"),
+		"example.com/post-v2":    page("/post-v2", edited, ""),
+		"example.com/garden":     page("/garden", other, ""),
+		"example.com/gone":       {URL: "https://example.com/gone", StatusCode: 404},
+	}
+
+	clusters := findDuplicates(pages, duplicateOptions{})
+
+	type summary struct {
+		ID   int
+		Kind string
+		URLs []string
+	}
+	var actual []summary
+	for _, cluster := range clusters {
+		s := summary{ID: cluster.ID, Kind: cluster.Kind}
+		for _, pageData := range cluster.Pages {
+			s.URLs = append(s.URLs, pageData.URL)
+		}
+		actual = append(actual, s)
+	}
+
+	expected := []summary{
+		{ID: 1, Kind: duplicateExact, URLs: []string{"https://example.com/post", "https://example.com/post-print"}},
+		{ID: 2, Kind: duplicateNear, URLs: []string{"https://example.com/post", "https://example.com/post-print", "https://example.com/post-v2"}},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestFindDuplicatesSkipsEmptyPages(t *testing.T) {
+	pages := map[string]PageData{}
+	for _, path := range []string{"/empty-a", "/empty-b"} {
+		pageData := extractPageData(articleHTML(""), "https://example.com"+path)
+		pageData.StatusCode = 200
+		pages["example.com"+path] = pageData
+	}
+
+	if clusters := findDuplicates(pages, duplicateOptions{}); len(clusters) != 0 {
+		t.Errorf("expected no clusters for empty pages, got %+v", clusters)
+	}
+}
+
+func TestSimHashBucketsFindEverySimilarPair(t *testing.T) {
+	// Fingerprints near a few seeds, so plenty of pairs are close
+	rng := rand.New(rand.NewSource(1))
+	var pages []PageData
+	for seed := 0; seed < 4; seed++ {
+		base := rng.Uint64()
+		for i := 0; i < 50; i++ {
+			hash := base
+			for flips := rng.Intn(12); flips > 0; flips-- {
+				hash ^= 1 << rng.Intn(64)
+			}
+			pages = append(pages, PageData{SimHash: simHash(hash)})
+		}
+	}
+
+	tests := []float64{1, 0.95, 0.9, 0.8, 0.5, 0.01}
+	for i, threshold := range tests {
+		candidates := make(map[[2]int]bool)
+		for _, bucket := range simHashBuckets(pages, threshold) {
+			for a, x := range bucket {
+				for _, y := range bucket[a+1:] {
+					candidates[[2]int{x, y}] = true
+				}
+			}
+		}
+		for x := range pages {
+			for y := x + 1; y < len(pages); y++ {
+				if pages[x].SimHash.similarity(pages[y].SimHash) >= threshold && !candidates[[2]int{x, y}] {
+					t.Fatalf("Test %v - threshold %v FAIL: pages %d and %d are similar but share no bucket", i, threshold, x, y)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package crawler
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// edgesHeader is the column layout of the link edge list
+var edgesHeader = []string{"source_url", "target_url", "anchor_text", "rel", "target", "title", "position", "kind", "internal"}
+
+// writeEdgesCSV writes one row per source→target link, for graph analysis
+// and anchor-text audits. Non-web links such as mailto: are included with
+// their kind.
+func writeEdgesCSV(pages map[string]PageData, filename string, opts CSVOptions) error {
+	var rows [][]string
+
+	// Sources in URL order, links in document order
+	for _, pageData := range sortPages(pages, SortByURL) {
+		sourceURL, err := url.Parse(pageData.URL)
+		if err != nil {
+			continue
+		}
+
+		for _, link := range pageData.Links {
+			internal := false
+			if targetURL, err := url.Parse(link.URL); err == nil {
+				internal = strings.EqualFold(targetURL.Host, sourceURL.Host)
+			}
+
+			rows = append(rows, []string{
+				pageData.URL,
+				link.URL,
+				link.AnchorText,
+				strings.Join(link.Rel, " "),
+				link.Target,
+				link.Title,
+				link.Position,
+				string(link.Kind),
+				strconv.FormatBool(internal),
+			})
+		}
+	}
+
+	return writeCSVRows(filename, opts, edgesHeader, rows)
+}
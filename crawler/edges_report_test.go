@@ -0,0 +1,45 @@
+package crawler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteEdgesCSV(t *testing.T) {
+	pages := map[string]PageData{
+		"example.com/b": {
+			URL: "https://example.com/b",
+			Links: []Link{
+				{URL: "https://example.com", AnchorText: "Home", Position: "nav", Kind: LinkPage},
+				{URL: "mailto:hi@example.com", AnchorText: "Email", Position: "footer", Kind: LinkMailto},
+			},
+		},
+		"example.com": {
+			URL: "https://example.com",
+			Links: []Link{
+				{URL: "https://example.com/b", AnchorText: "B, the page", Position: "main", Kind: LinkPage},
+				{URL: "https://other.com", AnchorText: "Ad", Rel: []string{"nofollow", "sponsored"}, Target: "_blank", Position: "aside", Kind: LinkPage},
+			},
+		},
+	}
+
+	filename := filepath.Join(t.TempDir(), "edges.csv")
+	if err := writeEdgesCSV(pages, filename, CSVOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	actual, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("couldn't read report: %v", err)
+	}
+
+	expected := "source_url,target_url,anchor_text,rel,target,title,position,kind,internal\n" +
+		"https://example.com,https://example.com/b,\"B, the page\",,,,main,page,true\n" +
+		"https://example.com,https://other.com,Ad,nofollow sponsored,_blank,,aside,page,false\n" +
+		"https://example.com/b,https://example.com,Home,,,,nav,page,true\n" +
+		"https://example.com/b,mailto:hi@example.com,Email,,,,footer,mailto,false\n"
+	if string(actual) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
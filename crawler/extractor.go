@@ -0,0 +1,195 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+// Extractor pulls custom fields out of a page, such as prices, SKUs,
+// article dates or breadcrumb trails. Every field it declares becomes a
+// report column and is saved with the crawl.
+type Extractor interface {
+	// Fields names every field Extract may set, in column order
+	Fields() []string
+
+	// Extract reads the parsed page and its response and sets fields.
+	// It runs on every HTML page, from many goroutines at once. Fields it
+	// didn't declare are dropped.
+	Extract(doc *goquery.Document, res Response, fields PageFields) error
+}
+
+// PageFields holds the custom fields of a page, keyed by field name. A
+// field may hold several values, like the steps of a breadcrumb trail.
+type PageFields map[string][]string
+
+// Set replaces a field's values
+func (f PageFields) Set(name string, values ...string) {
+	f[name] = values
+}
+
+// Get returns a field's first value, or "" when it has none
+func (f PageFields) Get(name string) string {
+	if values := f[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// fieldNamePattern keeps field names usable as --columns entries
+var fieldNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// extractorRegistry holds the extractors run on every crawl
+var extractorRegistry struct {
+	mu         sync.RWMutex
+	extractors []Extractor
+}
+
+// RegisterExtractor adds an extractor to every crawl in the process. Its
+// fields must not clash with a built-in column or another extractor's.
+func RegisterExtractor(extractor Extractor) error {
+	extractorRegistry.mu.Lock()
+	defer extractorRegistry.mu.Unlock()
+
+	extractors := append(extractorRegistry.extractors, extractor)
+	if err := checkExtractors(extractors); err != nil {
+		return err
+	}
+	extractorRegistry.extractors = extractors
+	return nil
+}
+
+// registeredExtractors returns the extractors added with RegisterExtractor
+func registeredExtractors() []Extractor {
+	extractorRegistry.mu.RLock()
+	defer extractorRegistry.mu.RUnlock()
+	return append([]Extractor(nil), extractorRegistry.extractors...)
+}
+
+// checkExtractors reports invalid or clashing field names
+func checkExtractors(extractors []Extractor) error {
+	seen := make(map[string]bool)
+	for _, col := range pageDataColumns {
+		seen[col.Name] = true
+	}
+	for _, extractor := range extractors {
+		for _, name := range extractor.Fields() {
+			if !fieldNamePattern.MatchString(name) {
+				return fmt.Errorf("invalid field name %q (use letters, digits, '_', '.' and '-')", name)
+			}
+			if seen[name] {
+				return fmt.Errorf("field %q is already a report column", name)
+			}
+			seen[name] = true
+		}
+	}
+	return nil
+}
+
+// extractorFields lists the fields the extractors declare, in order
+func extractorFields(extractors []Extractor) []string {
+	var names []string
+	for _, extractor := range extractors {
+		names = append(names, extractor.Fields()...)
+	}
+	return names
+}
+
+// runExtractors runs each extractor over a page and collects the fields
+// they declared. A failing extractor keeps the fields it set.
+func runExtractors(extractors []Extractor, doc *goquery.Document, res Response) (PageFields, error) {
+	if len(extractors) == 0 {
+		return nil, nil
+	}
+
+	fields := make(PageFields)
+	var errs []error
+	for _, extractor := range extractors {
+		set := make(PageFields)
+		if err := extractor.Extract(doc, res, set); err != nil {
+			errs = append(errs, fmt.Errorf("couldn't extract %s: %w", strings.Join(extractor.Fields(), ", "), err))
+		}
+		for _, name := range extractor.Fields() {
+			if values, ok := set[name]; ok {
+				fields[name] = values
+			}
+		}
+	}
+	return fields, errors.Join(errs...)
+}
+
+// attributeSuffixPattern matches the "@name" ending of a selector
+var attributeSuffixPattern = regexp.MustCompile(`@([A-Za-z_:][-A-Za-z0-9_:.]*)\s*$`)
+
+// selectorExtractor sets a field to the text, or an attribute, of every
+// element matching a CSS selector
+type selectorExtractor struct {
+	field     string
+	selector  cascadia.Selector
+	attribute string // Empty means the element's text
+}
+
+// NewSelectorExtractor creates an extractor for one field from a CSS
+// selector. A selector ending in "@name" reads that attribute instead of
+// the text, e.g. `meta[property="article:published_time"]@content`.
+func NewSelectorExtractor(field, selector string) (Extractor, error) {
+	if err := checkExtractors([]Extractor{&selectorExtractor{field: field}}); err != nil {
+		return nil, err
+	}
+
+	attribute := ""
+	if match := attributeSuffixPattern.FindStringSubmatchIndex(selector); match != nil {
+		selector, attribute = selector[:match[0]], selector[match[2]:match[3]]
+	}
+	compiled, err := cascadia.Compile(strings.TrimSpace(selector))
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector for %s: %w", field, err)
+	}
+	return &selectorExtractor{field: field, selector: compiled, attribute: attribute}, nil
+}
+
+func (e *selectorExtractor) Fields() []string {
+	return []string{e.field}
+}
+
+// Extract collects one value per matching element, skipping empty ones
+func (e *selectorExtractor) Extract(doc *goquery.Document, res Response, fields PageFields) error {
+	var values []string
+	doc.FindMatcher(e.selector).Each(func(_ int, s *goquery.Selection) {
+		var value string
+		if e.attribute != "" {
+			value = s.AttrOr(e.attribute, "")
+		} else {
+			value = s.Text()
+		}
+		if value = strings.Join(strings.Fields(value), " "); value != "" {
+			values = append(values, value)
+		}
+	})
+	if len(values) > 0 {
+		fields.Set(e.field, values...)
+	}
+	return nil
+}
+
+// sortedFieldNames returns the distinct custom field names set on pages
+func sortedFieldNames(pages map[string]PageData) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, pageData := range pages {
+		for name := range pageData.Fields {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,171 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestSelectorExtractor(t *testing.T) {
+	html := `<html><head><meta property="article:published_time" content="2024-05-01"></head><body>
+<nav class="breadcrumb"><a href="/">Home</a> <a href="/shop">Shop</a> <a href="/shop/tea"> Green
+	tea </a></nav>
+<span class="price">$12.50</span><span class="sku" data-sku="GT-100"></span>
+</body></html>`
+
+	tests := []struct {
+		name     string
+		field    string
+		selector string
+		expected []string
+	}{
+		{"text", "price", ".price", []string{"$12.50"}},
+		{"attribute", "published", `meta[property="article:published_time"]@content`, []string{"2024-05-01"}},
+		{"every match, whitespace collapsed", "breadcrumbs", ".breadcrumb a", []string{"Home", "Shop", "Green tea"}},
+		{"empty values skipped", "sku_text", ".sku", nil},
+		{"data attribute", "sku", ".sku@data-sku", []string{"GT-100"}},
+		{"no match", "rating", ".rating", nil},
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			extractor, err := NewSelectorExtractor(tc.field, tc.selector)
+			if err != nil {
+				t.Fatalf("Test %v - %s FAIL: unexpected error: %v", i, tc.name, err)
+			}
+			fields := PageFields{}
+			if err := extractor.Extract(doc, Response{}, fields); err != nil {
+				t.Fatalf("Test %v - %s FAIL: unexpected error: %v", i, tc.name, err)
+			}
+			if actual := fields[tc.field]; !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Test %v - %s FAIL: expected %q, got %q", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+
+	for _, bad := range [][2]string{{"price", "span["}, {"", ".price"}, {"unit price", ".price"}, {"title", "h1"}} {
+		if _, err := NewSelectorExtractor(bad[0], bad[1]); err == nil {
+			t.Errorf("expected field %q with selector %q to be rejected", bad[0], bad[1])
+		}
+	}
+}
+
+// productExtractor reads the price and SKU of a product page, failing on
+// pages without a price
+type productExtractor struct{}
+
+func (productExtractor) Fields() []string { return []string{"price", "sku"} }
+
+func (productExtractor) Extract(doc *goquery.Document, res Response, fields PageFields) error {
+	fields.Set("sku", res.Header.Get("X-Sku"))
+	fields.Set("ignored", "not declared")
+	price := doc.Find(".price").Text()
+	if price == "" {
+		return errors.New("no price")
+	}
+	fields.Set("price", price)
+	return nil
+}
+
+func TestExtractorsFlowIntoReports(t *testing.T) {
+	site := MemoryFetcher{
+		"https://shop.example":     {Body: `<a href="/tea">Tea</a>`},
+		"https://shop.example/tea": {Header: map[string][]string{"X-Sku": {"GT-100"}}, Body: `<span class="price">12.50</span>`},
+	}
+	crumbs, err := NewSelectorExtractor("breadcrumbs", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var log strings.Builder
+	c, err := New(Options{URL: "https://shop.example", Fetcher: site, Extractors: []Extractor{productExtractor{}, crumbs}, Log: &log})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fields land on the pages and the snapshot lists them
+	if expected := []string{"price", "sku", "breadcrumbs"}; !reflect.DeepEqual(snapshot.Fields, expected) {
+		t.Errorf("expected snapshot fields %v, got %v", expected, snapshot.Fields)
+	}
+	expected := PageFields{"price": {"12.50"}, "sku": {"GT-100"}}
+	if actual := snapshot.Pages["shop.example/tea"].Fields; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected fields %v, got %v", expected, actual)
+	}
+	if !strings.Contains(log.String(), "couldn't extract price, sku: no price") {
+		t.Errorf("expected the extractor error in the log, got %q", log.String())
+	}
+
+	// Every report format picks them up
+	dir := t.TempDir()
+	if err := WriteReports(snapshot, ReportOptions{OutputDir: dir}, &strings.Builder{}); err != nil {
+		t.Fatal(err)
+	}
+	report, _ := os.ReadFile(filepath.Join(dir, "report.csv"))
+	lines := strings.Split(string(report), "\n")
+	if !strings.HasSuffix(lines[0], ",price,sku,breadcrumbs") {
+		t.Errorf("expected the custom fields as columns, got header %q", lines[0])
+	}
+	if !strings.Contains(string(report), ",12.50,GT-100,") || !strings.Contains(string(report), ",,,Tea") {
+		t.Errorf("expected the custom field values in report.csv:\n%s", report)
+	}
+	graphML, _ := os.ReadFile(filepath.Join(dir, "linkgraph.graphml"))
+	if !strings.Contains(string(graphML), `<data key="price">12.50</data>`) {
+		t.Errorf("expected the custom fields in the GraphML export:\n%s", graphML)
+	}
+
+	// A changed field shows up in the diff
+	changed := Snapshot{Fields: snapshot.Fields, Pages: map[string]PageData{}}
+	for key, page := range snapshot.Pages {
+		changed.Pages[key] = page
+	}
+	page := changed.Pages["shop.example/tea"]
+	page.Fields = PageFields{"price": {"14.00"}, "sku": {"GT-100"}}
+	changed.Pages["shop.example/tea"] = page
+	changes, err := DiffSnapshots(snapshot, changed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Field != "price" || changes[0].Old != "12.50" || changes[0].New != "14.00" {
+		t.Errorf("expected one price change, got %+v", changes)
+	}
+}
+
+func TestRegisterExtractor(t *testing.T) {
+	defer func(saved []Extractor) { extractorRegistry.extractors = saved }(extractorRegistry.extractors)
+
+	if err := RegisterExtractor(productExtractor{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterExtractor(productExtractor{}); err == nil || !strings.Contains(err.Error(), `field "price" is already a report column`) {
+		t.Errorf("expected a duplicate field to be rejected, got %v", err)
+	}
+	if err := RegisterExtractor(&selectorExtractor{field: "title"}); err == nil {
+		t.Error("expected a built-in column name to be rejected")
+	}
+
+	// Registered extractors run on every crawl, before the crawl's own
+	c, err := New(Options{URL: "https://shop.example", Fetcher: MemoryFetcher{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields := c.Fields(); !reflect.DeepEqual(fields, []string{"price", "sku"}) {
+		t.Errorf("expected the registered fields, got %v", fields)
+	}
+	if _, err := New(Options{URL: "https://shop.example", Extractors: []Extractor{productExtractor{}}}); err == nil {
+		t.Error("expected a crawl extractor clashing with a registered one to be rejected")
+	}
+}
@@ -0,0 +1,226 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultUserAgent identifies the crawler on every request it makes
+const DefaultUserAgent = "BootCrawler/1.0"
+
+// FetchOptions configures the requests the crawler makes
+type FetchOptions struct {
+	UserAgent  string            // Empty means DefaultUserAgent
+	Headers    map[string]string // Extra headers sent with every request
+	Timeout    time.Duration     // Zero means no timeout
+	RateLimit  float64           // Requests per second across all workers; zero means unlimited
+	CookieFile string            // Netscape cookies.txt loaded into the cookie jar
+	Auth       []AuthSpec        // Per-host credentials, read when the fetcher is created
+	Login      LoginOptions      // Form login run by logIn, and again when the session is lost
+}
+
+// httpFetcher is the Fetcher the crawler uses unless Options.Fetcher is set.
+// It sends the crawler's requests with the configured headers,
+// credentials, cookies, timeout and rate limit
+type httpFetcher struct {
+	opts        FetchOptions
+	client      *http.Client
+	credentials []hostCredential
+	login       *formLogin // Nil without a login form
+
+	mu   sync.Mutex
+	next time.Time // Earliest time the next request may start
+}
+
+// newHTTPFetcher creates a fetcher for the given options, reading the
+// cookies file and credentials. Its cookie jar keeps every Set-Cookie for
+// later requests.
+func newHTTPFetcher(opts FetchOptions) (*httpFetcher, error) {
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+	jar, err := newCookieJar(opts.CookieFile)
+	if err != nil {
+		return nil, err
+	}
+	credentials, err := loadCredentials(opts.Auth)
+	if err != nil {
+		return nil, err
+	}
+	fetcher := &httpFetcher{
+		opts:        opts,
+		client:      &http.Client{Timeout: opts.Timeout, Jar: jar},
+		credentials: credentials,
+	}
+	if opts.Login.Enabled() {
+		if err := opts.Login.Validate(); err != nil {
+			return nil, err
+		}
+		fetcher.login = &formLogin{opts: opts.Login}
+	}
+	return fetcher, nil
+}
+
+// loginFetcher is a Fetcher that may need to log in before the crawl
+type loginFetcher interface {
+	logIn(log io.Writer) error
+}
+
+// logIn runs the form login, if one is configured. Call it before crawling.
+func (f *httpFetcher) logIn(log io.Writer) error {
+	if f.login == nil {
+		return nil
+	}
+	fmt.Fprintf(log, "logging in at: %s\n", f.opts.Login.URL)
+	return f.login.logIn(f)
+}
+
+// newRequest creates a request carrying the User-Agent, extra headers and
+// any credentials for the request's host
+func (f *httpFetcher) newRequest(method, rawURL string) (*http.Request, error) {
+	req, err := http.NewRequest(method, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Identify our crawler, then add the configured headers
+	req.Header.Set("User-Agent", f.opts.UserAgent)
+	for name, value := range f.opts.Headers {
+		req.Header.Set(name, value)
+	}
+	authorize(req, f.credentials)
+	return req, nil
+}
+
+// do sends a request once the rate limit allows it
+func (f *httpFetcher) do(req *http.Request) (*http.Response, error) {
+	if err := f.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return f.client.Do(req)
+}
+
+// wait blocks until the next request slot or until ctx is done. Slots are
+// handed out in order, 1/RateLimit seconds apart, however many workers are
+// waiting.
+func (f *httpFetcher) wait(ctx context.Context) error {
+	if f.opts.RateLimit <= 0 {
+		return nil
+	}
+	interval := time.Duration(float64(time.Second) / f.opts.RateLimit)
+
+	f.mu.Lock()
+	now := time.Now()
+	start := f.next
+	if start.Before(now) {
+		start = now
+	}
+	f.next = start.Add(interval)
+	f.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Fetch performs a GET request and returns the response without judging
+// its status code or content type. When the site shows the session was
+// lost, it logs in again and retries once.
+func (f *httpFetcher) Fetch(ctx context.Context, rawURL string) (Response, error) {
+	if f.login == nil {
+		return f.fetchOnce(ctx, rawURL)
+	}
+
+	generation := f.login.currentGeneration()
+	result, err := f.fetchOnce(ctx, rawURL)
+	if err != nil || !f.login.loggedOut(rawURL, result) {
+		return result, err
+	}
+	if err := f.login.relogIn(f, generation); err != nil {
+		return result, fmt.Errorf("logged out and couldn't log in again: %w", err)
+	}
+	return f.fetchOnce(ctx, rawURL)
+}
+
+// fetchOnce performs a single GET request
+func (f *httpFetcher) fetchOnce(ctx context.Context, rawURL string) (Response, error) {
+	// Create GET request
+	req, err := f.newRequest("GET", rawURL)
+	if err != nil {
+		return Response{}, err
+	}
+	req = req.WithContext(ctx)
+
+	// Execute the request
+	resp, err := f.do(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	result := Response{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Header:      resp.Header,
+		FinalURL:    resp.Request.URL.String(),
+	}
+
+	// Only pages and sitemaps are worth reading
+	if resp.StatusCode >= 400 || !result.hasTextBody() {
+		return result, nil
+	}
+
+	// Read the response body
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return result, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	result.Body = string(bodyBytes)
+	return result, nil
+}
+
+// Head asks for rawURL's headers with HEAD, through the rate limit and
+// timeout like every other request. Servers that refuse HEAD are asked
+// again with GET, without reading the body. The returned header always
+// carries Content-Length when the size is known.
+func (f *httpFetcher) Head(ctx context.Context, rawURL string) (Response, error) {
+	resp, err := f.headOnce(ctx, http.MethodHead, rawURL)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp, err = f.headOnce(ctx, http.MethodGet, rawURL)
+	}
+	return resp, err
+}
+
+// headOnce sends a single request and keeps only its headers
+func (f *httpFetcher) headOnce(ctx context.Context, method, rawURL string) (Response, error) {
+	req, err := f.newRequest(method, rawURL)
+	if err != nil {
+		return Response{}, err
+	}
+	resp, err := f.do(req.WithContext(ctx))
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.ContentLength >= 0 {
+		resp.Header.Set("Content-Length", strconv.FormatInt(resp.ContentLength, 10))
+	}
+	return Response{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Header:      resp.Header,
+		FinalURL:    resp.Request.URL.String(),
+	}, nil
+}
@@ -0,0 +1,51 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPFetcherOptions(t *testing.T) {
+	var agents, teams []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.Header.Get("User-Agent"))
+		teams = append(teams, r.Header.Get("X-Team"))
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	fetcher, err := newHTTPFetcher(FetchOptions{
+		UserAgent: "StagingBot/2.0",
+		Headers:   map[string]string{"X-Team": "seo"},
+		RateLimit: 20, // One request every 50ms
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		req, err := fetcher.newRequest("GET", server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := fetcher.do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	// The first request goes right away, the next two wait their turn
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected the rate limit to space out requests, took %v", elapsed)
+	}
+	for i := range agents {
+		if agents[i] != "StagingBot/2.0" || teams[i] != "seo" {
+			t.Errorf("request %d: expected custom headers, got User-Agent %q and X-Team %q", i, agents[i], teams[i])
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Fetcher gets pages and sitemaps for the crawler. It is called from many
+// goroutines at once. Implementations return the response whatever its
+// status code; an error means there was no response at all.
+type Fetcher interface {
+	Fetch(ctx context.Context, rawURL string) (Response, error)
+}
+
+// HeadFetcher is a Fetcher that can also ask for a URL's headers without
+// its body. The image audit probes images through it, so probes share the
+// crawl's credentials, cookies, timeout and rate limit, and replayed crawls
+// stay offline.
+type HeadFetcher interface {
+	Fetcher
+	Head(ctx context.Context, rawURL string) (Response, error)
+}
+
+// Response holds the parts of an HTTP response the crawler cares about
+type Response struct {
+	StatusCode  int         `json:"status_code"`
+	ContentType string      `json:"content_type"`
+	Header      http.Header `json:"header,omitempty"`
+	FinalURL    string      `json:"final_url,omitempty"` // URL after following redirects
+	Body        string      `json:"body,omitempty"`      // Only read for HTML, XML and plain text
+}
+
+// isHTML reports whether the response declared an HTML body
+func (res Response) isHTML() bool {
+	return strings.HasPrefix(res.ContentType, "text/html")
+}
+
+// hasTextBody reports whether the body may be a page or a sitemap
+func (res Response) hasTextBody() bool {
+	return res.isHTML() || strings.Contains(res.ContentType, "xml") || strings.HasPrefix(res.ContentType, "text/plain")
+}
+
+// NewHTTPFetcher creates the fetcher the crawler uses by default, sending
+// requests with the given headers, credentials, cookies, timeout and rate
+// limit. Wrap it in a Recorder to save a crawl for replay.
+func NewHTTPFetcher(opts FetchOptions) (Fetcher, error) {
+	return newHTTPFetcher(opts)
+}
+
+// MemoryFetcher serves responses from a map keyed by URL, for tests and
+// offline crawls. A zero StatusCode means 200 and an empty ContentType
+// means text/html; URLs not in the map answer 404.
+type MemoryFetcher map[string]Response
+
+// Fetch returns the response stored for rawURL
+func (m MemoryFetcher) Fetch(ctx context.Context, rawURL string) (Response, error) {
+	if err := ctx.Err(); err != nil {
+		return Response{}, err
+	}
+	res, ok := m[rawURL]
+	if !ok {
+		return Response{StatusCode: http.StatusNotFound, ContentType: "text/plain", FinalURL: rawURL}, nil
+	}
+	if res.StatusCode == 0 {
+		res.StatusCode = http.StatusOK
+	}
+	if res.ContentType == "" {
+		res.ContentType = "text/html"
+	}
+	if res.FinalURL == "" {
+		res.FinalURL = rawURL
+	}
+	return res, nil
+}
+
+// Head returns the response stored for rawURL without its body. The
+// Content-Length header defaults to the length of the stored body.
+func (m MemoryFetcher) Head(ctx context.Context, rawURL string) (Response, error) {
+	res, err := m.Fetch(ctx, rawURL)
+	if err != nil {
+		return res, err
+	}
+	res.Header = res.Header.Clone()
+	if res.Header == nil {
+		res.Header = http.Header{}
+	}
+	if res.Header.Get("Content-Length") == "" {
+		res.Header.Set("Content-Length", strconv.Itoa(len(res.Body)))
+	}
+	res.Body = ""
+	return res, nil
+}
+
+// headFetcher returns f as a HeadFetcher when it can send HEAD requests,
+// looking through a Recorder to the fetcher it wraps
+func headFetcher(f Fetcher) (HeadFetcher, bool) {
+	if recorder, ok := f.(*Recorder); ok {
+		if _, ok := recorder.next.(HeadFetcher); !ok {
+			return nil, false
+		}
+	}
+	hf, ok := f.(HeadFetcher)
+	return hf, ok
+}
@@ -0,0 +1,131 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Asset types
+const (
+	assetScript     = "script"
+	assetStylesheet = "stylesheet"
+	assetFont       = "font"
+	assetImage      = "image" // Only from preload/prefetch hints; page images are in ImageURLs
+	assetIframe     = "iframe"
+	assetVideo      = "video"
+	assetAudio      = "audio"
+	assetFavicon    = "favicon"
+	assetManifest   = "manifest"
+	assetOther      = "other" // Hinted resources of an unknown type, e.g. prefetched pages
+)
+
+// Asset is a resource a page loads or hints at, other than links and images
+type Asset struct {
+	URL  string // Absolute asset URL
+	Type string // One of the asset types above
+	Hint string // Resource hint that referenced it (preload, prefetch, modulepreload), or "" when loaded directly
+}
+
+// String formats the asset as "type=url" for reports, marking hinted assets
+func (asset Asset) String() string {
+	if asset.Hint != "" {
+		return asset.Type + "(" + asset.Hint + ")=" + asset.URL
+	}
+	return asset.Type + "=" + asset.URL
+}
+
+// preloadAsTypes maps the "as" attribute of preload hints onto asset types
+var preloadAsTypes = map[string]string{
+	"script":   assetScript,
+	"style":    assetStylesheet,
+	"font":     assetFont,
+	"image":    assetImage,
+	"video":    assetVideo,
+	"audio":    assetAudio,
+	"document": assetIframe,
+}
+
+// fontFacePattern matches @font-face rules in CSS
+var fontFacePattern = regexp.MustCompile(`(?is)@font-face\s*{[^}]*}`)
+
+func getAssetsFromHTML(htmlBody string, baseURL *url.URL) ([]Asset, error) {
+	doc, err := parseHTML(htmlBody)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse HTML: %w", err)
+	}
+
+	return assetsFromDocument(doc, baseURL), nil
+}
+
+// assetsFromDocument collects scripts, stylesheets, resource hints, fonts,
+// iframes, media, favicons and the web app manifest, in document order
+func assetsFromDocument(doc *goquery.Document, baseURL *url.URL) []Asset {
+	var assets []Asset
+
+	// Resolve assets against the document base
+	baseURL = documentBaseURL(doc, baseURL)
+
+	add := func(rawURL, assetType, hint string) {
+		absoluteURL := resolveAgainst(baseURL, rawURL)
+		if absoluteURL == "" || !strings.HasPrefix(absoluteURL, "http") {
+			return // Skip data:, about:blank and javascript: sources
+		}
+		assets = append(assets, Asset{URL: absoluteURL, Type: assetType, Hint: hint})
+	}
+
+	doc.Find("script[src], link[rel][href], iframe[src], video, audio, style").Each(func(_ int, s *goquery.Selection) {
+		switch goquery.NodeName(s) {
+		case "script":
+			add(s.AttrOr("src", ""), assetScript, "")
+
+		case "link":
+			href := s.AttrOr("href", "")
+			for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+				switch rel {
+				case "stylesheet":
+					add(href, assetStylesheet, "")
+				case "icon", "apple-touch-icon", "apple-touch-icon-precomposed", "mask-icon":
+					add(href, assetFavicon, "")
+				case "manifest":
+					add(href, assetManifest, "")
+				case "modulepreload":
+					add(href, assetScript, rel)
+				case "preload", "prefetch":
+					assetType, ok := preloadAsTypes[strings.ToLower(s.AttrOr("as", ""))]
+					if !ok {
+						assetType = assetOther
+					}
+					add(href, assetType, rel)
+				}
+			}
+
+		case "iframe":
+			add(s.AttrOr("src", ""), assetIframe, "")
+
+		case "video", "audio":
+			mediaType := assetVideo
+			if goquery.NodeName(s) == "audio" {
+				mediaType = assetAudio
+			}
+			if src, ok := s.Attr("src"); ok {
+				add(src, mediaType, "")
+			}
+			s.Find("source[src], track[src]").Each(func(_ int, source *goquery.Selection) {
+				add(source.AttrOr("src", ""), mediaType, "")
+			})
+
+		case "style":
+			for _, rule := range fontFacePattern.FindAllString(s.Text(), -1) {
+				for _, match := range cssURLPattern.FindAllStringSubmatch(rule, -1) {
+					add(match[1], assetFont, "")
+				}
+			}
+		}
+	})
+
+	return assets
+}
@@ -0,0 +1,73 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// parseHTML parses an HTML string into a goquery document
+func parseHTML(html string) (*goquery.Document, error) {
+	reader := strings.NewReader(html)
+	return goquery.NewDocumentFromReader(reader)
+}
+
+func getH1FromHTML(html string) string {
+	// Parse HTML
+	doc, err := parseHTML(html)
+	if err != nil {
+		return ""
+	}
+
+	return h1FromDocument(doc)
+}
+
+func h1FromDocument(doc *goquery.Document) string {
+	// Find first <h1> tag and get its text
+	return doc.Find("h1").First().Text()
+}
+
+func getFirstParagraphFromHTML(html string) string {
+	doc, err := parseHTML(html)
+	if err != nil {
+		return ""
+	}
+
+	return firstParagraphFromDocument(doc)
+}
+
+func firstParagraphFromDocument(doc *goquery.Document) string {
+	// Try to find <main> tag first
+	mainTag := doc.Find("main")
+
+	var pText string
+
+	// If <main> exists, look for <p> inside it
+	if mainTag.Length() > 0 {
+		pText = mainTag.Find("p").First().Text()
+	}
+
+	// If no <main> or no <p> in <main>, fallback to first <p> in document
+	if pText == "" {
+		pText = doc.Find("p").First().Text()
+	}
+
+	return pText
+}
+
+// documentBaseURL returns the URL relative references resolve against:
+// the first <base href>, itself resolved against the page URL, or the page
+// URL. Extractors call it to honor <base href> when resolving relative URLs.
+func documentBaseURL(doc *goquery.Document, pageURL *url.URL) *url.URL {
+	href, exists := doc.Find("base[href]").First().Attr("href")
+	if !exists {
+		return pageURL
+	}
+
+	parsedHref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return pageURL
+	}
+	return pageURL.ResolveReference(parsedHref)
+}
@@ -1,4 +1,4 @@
-package main
+package crawler
 
 import "testing"
 
@@ -114,4 +114,3 @@ func TestGetFirstParagraphFromHTMLEmptyMain(t *testing.T) {
 		t.Errorf("expected %q, got %q", expected, actual)
 	}
 }
-
@@ -0,0 +1,212 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ImageCandidate is one image URL found on a page and where it came from.
+// Candidates from an <img> also carry the element's attributes.
+type ImageCandidate struct {
+	URL     string // Absolute image URL
+	Source  string // Attribute or construct it came from, e.g. src, srcset, data-src or css
+	IsImg   bool   // Came from an <img> element, so the fields below apply
+	HasAlt  bool   // The <img> has an alt attribute, possibly empty
+	Alt     string
+	Title   string
+	Width   string // width attribute as written
+	Height  string // height attribute as written
+	Loading string // loading attribute, e.g. lazy
+}
+
+// String formats the candidate as "source=url" for reports
+func (image ImageCandidate) String() string {
+	return image.Source + "=" + image.URL
+}
+
+// lazyImageAttributes are the attributes lazy-loading libraries use in place of src
+var lazyImageAttributes = []string{"data-src", "data-lazy-src", "data-original"}
+
+// cssURLPattern matches url(...) references in CSS, quoted or not
+var cssURLPattern = regexp.MustCompile(`url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+
+// cssBackgroundPattern matches background and background-image declarations
+var cssBackgroundPattern = regexp.MustCompile(`(?i)background(?:-image)?\s*:([^;}]*)`)
+
+func getImagesFromHTML(htmlBody string, baseURL *url.URL) ([]string, error) {
+	doc, err := parseHTML(htmlBody)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse HTML: %w", err)
+	}
+
+	return imagesFromDocument(doc, baseURL), nil
+}
+
+// imagesFromDocument returns every distinct image URL on the page, in the
+// order first seen
+func imagesFromDocument(doc *goquery.Document, baseURL *url.URL) []string {
+	return imageCandidateURLs(imageCandidatesFromDocument(doc, baseURL))
+}
+
+// imageCandidateURLs returns the distinct URLs of the candidates, in order
+func imageCandidateURLs(images []ImageCandidate) []string {
+	var imageURLs []string
+	seen := make(map[string]bool)
+
+	for _, image := range images {
+		if !seen[image.URL] {
+			seen[image.URL] = true
+			imageURLs = append(imageURLs, image.URL)
+		}
+	}
+
+	return imageURLs
+}
+
+// getImageCandidatesFromHTML returns every image candidate on the page with its source
+func getImageCandidatesFromHTML(htmlBody string, baseURL *url.URL) ([]ImageCandidate, error) {
+	doc, err := parseHTML(htmlBody)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse HTML: %w", err)
+	}
+
+	return imageCandidatesFromDocument(doc, baseURL), nil
+}
+
+// imageCandidatesFromDocument collects images from <img src>, srcset,
+// lazy-load attributes, <picture><source> and CSS backgrounds, resolved
+// against the document base URL
+func imageCandidatesFromDocument(doc *goquery.Document, baseURL *url.URL) []ImageCandidate {
+	var images []ImageCandidate
+
+	// Resolve images against the document base
+	baseURL = documentBaseURL(doc, baseURL)
+
+	add := func(rawURL string, image ImageCandidate) {
+		rawURL = strings.TrimSpace(rawURL)
+		if rawURL == "" || strings.HasPrefix(strings.ToLower(rawURL), "data:") {
+			return // Inline images aren't separate resources
+		}
+		if image.URL = resolveAgainst(baseURL, rawURL); image.URL != "" {
+			images = append(images, image)
+		}
+	}
+
+	doc.Find("img, picture source").Each(func(_ int, s *goquery.Selection) {
+		// Tell <picture><source> candidates apart from the <img> fallback
+		prefix := ""
+		element := ImageCandidate{}
+		if goquery.NodeName(s) == "source" {
+			prefix = "source "
+		} else {
+			element = imgAttributes(s)
+		}
+		add := func(rawURL, source string) {
+			image := element
+			image.Source = source
+			add(rawURL, image)
+		}
+
+		if src, ok := s.Attr("src"); ok && prefix == "" {
+			add(src, "src")
+		}
+		for _, rawURL := range parseSrcset(s.AttrOr("srcset", "")) {
+			add(rawURL, prefix+"srcset")
+		}
+		for _, attr := range lazyImageAttributes {
+			if value, ok := s.Attr(attr); ok {
+				add(value, prefix+attr)
+			}
+		}
+		for _, rawURL := range parseSrcset(s.AttrOr("data-srcset", "")) {
+			add(rawURL, prefix+"data-srcset")
+		}
+	})
+
+	// Inline style attributes, then <style> blocks
+	doc.Find("[style]").Each(func(_ int, s *goquery.Selection) {
+		for _, rawURL := range cssBackgroundURLs(s.AttrOr("style", "")) {
+			add(rawURL, ImageCandidate{Source: "style"})
+		}
+	})
+	doc.Find("style").Each(func(_ int, s *goquery.Selection) {
+		for _, rawURL := range cssBackgroundURLs(s.Text()) {
+			add(rawURL, ImageCandidate{Source: "css"})
+		}
+	})
+
+	return images
+}
+
+// imgAttributes reads the audit-relevant attributes of an <img>
+func imgAttributes(s *goquery.Selection) ImageCandidate {
+	alt, hasAlt := s.Attr("alt")
+	return ImageCandidate{
+		IsImg:   true,
+		HasAlt:  hasAlt,
+		Alt:     collapseWhitespace(alt),
+		Title:   collapseWhitespace(s.AttrOr("title", "")),
+		Width:   strings.TrimSpace(s.AttrOr("width", "")),
+		Height:  strings.TrimSpace(s.AttrOr("height", "")),
+		Loading: strings.ToLower(strings.TrimSpace(s.AttrOr("loading", ""))),
+	}
+}
+
+// parseSrcset returns the URLs of a srcset attribute, dropping the width and
+// density descriptors. URLs may contain commas, so candidates are split on
+// whitespace first as the HTML spec describes.
+func parseSrcset(srcset string) []string {
+	var urls []string
+
+	rest := srcset
+	for {
+		rest = strings.TrimLeft(rest, " \t\n\r\f,")
+		if rest == "" {
+			return urls
+		}
+
+		// The URL runs up to the next whitespace
+		end := strings.IndexAny(rest, " \t\n\r\f")
+		if end == -1 {
+			end = len(rest)
+		}
+		rawURL := rest[:end]
+		rest = rest[end:]
+
+		// A trailing comma ends the candidate with no descriptors
+		if trimmed := strings.TrimRight(rawURL, ","); trimmed != rawURL {
+			urls = append(urls, trimmed)
+			continue
+		}
+		urls = append(urls, rawURL)
+
+		// Skip the descriptors up to the next comma
+		if comma := strings.IndexByte(rest, ','); comma != -1 {
+			rest = rest[comma+1:]
+		} else {
+			rest = ""
+		}
+	}
+}
+
+// cssBackgroundURLs returns the url() references in background and
+// background-image declarations
+func cssBackgroundURLs(css string) []string {
+	var urls []string
+	for _, declaration := range cssBackgroundPattern.FindAllStringSubmatch(css, -1) {
+		for _, match := range cssURLPattern.FindAllStringSubmatch(declaration[1], -1) {
+			urls = append(urls, match[1])
+		}
+	}
+	return urls
+}
+
+// imagesMissingAltFromDocument counts <img> tags with no alt attribute at all.
+// An empty alt="" is deliberate (decorative images) and isn't counted.
+func imagesMissingAltFromDocument(doc *goquery.Document) int {
+	return doc.Find("img:not([alt])").Length()
+}
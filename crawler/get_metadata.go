@@ -0,0 +1,136 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Heading is one <h1>-<h6> element, in document order
+type Heading struct {
+	Level int // 1 for <h1> through 6 for <h6>
+	Text  string
+}
+
+// String formats the heading as "h2: Text" for reports
+func (h Heading) String() string {
+	return fmt.Sprintf("h%d: %s", h.Level, h.Text)
+}
+
+// HreflangLink is a <link rel="alternate" hreflang="..."> alternate
+type HreflangLink struct {
+	Lang string // Language/region code, e.g. en-gb or x-default
+	URL  string // Absolute URL of the alternate
+}
+
+// String formats the alternate as "lang=url" for reports
+func (h HreflangLink) String() string {
+	return h.Lang + "=" + h.URL
+}
+
+// seoMetadata holds the <head> metadata search engines care about
+type seoMetadata struct {
+	Title           string
+	MetaDescription string
+	MetaRobots      string
+	Canonical       string
+	Hreflang        []HreflangLink
+	OpenGraph       map[string]string
+	TwitterCard     map[string]string
+	Viewport        string
+	Lang            string
+	Headings        []Heading
+}
+
+// getSEOMetadataFromHTML extracts the SEO metadata of a page.
+// baseURL resolves relative canonical and hreflang URLs.
+func getSEOMetadataFromHTML(html string, baseURL *url.URL) (seoMetadata, error) {
+	doc, err := parseHTML(html)
+	if err != nil {
+		return seoMetadata{}, fmt.Errorf("couldn't parse HTML: %w", err)
+	}
+
+	return seoMetadataFromDocument(doc, baseURL), nil
+}
+
+func seoMetadataFromDocument(doc *goquery.Document, baseURL *url.URL) seoMetadata {
+	baseURL = documentBaseURL(doc, baseURL)
+
+	meta := seoMetadata{
+		Title:       collapseWhitespace(doc.Find("title").First().Text()),
+		Lang:        strings.TrimSpace(doc.Find("html").First().AttrOr("lang", "")),
+		OpenGraph:   map[string]string{},
+		TwitterCard: map[string]string{},
+	}
+
+	// <meta> names are case-insensitive, so match them by hand rather than by selector
+	doc.Find("meta").Each(func(_ int, s *goquery.Selection) {
+		name := strings.ToLower(strings.TrimSpace(s.AttrOr("name", s.AttrOr("property", ""))))
+		content := strings.TrimSpace(s.AttrOr("content", ""))
+
+		switch {
+		case name == "description" && meta.MetaDescription == "":
+			meta.MetaDescription = content
+		case name == "robots" && meta.MetaRobots == "":
+			meta.MetaRobots = content
+		case name == "viewport" && meta.Viewport == "":
+			meta.Viewport = content
+		case strings.HasPrefix(name, "og:"):
+			if _, exists := meta.OpenGraph[name]; !exists {
+				meta.OpenGraph[name] = content
+			}
+		case strings.HasPrefix(name, "twitter:"):
+			if _, exists := meta.TwitterCard[name]; !exists {
+				meta.TwitterCard[name] = content
+			}
+		}
+	})
+
+	doc.Find("link[rel][href]").Each(func(_ int, s *goquery.Selection) {
+		rels := strings.Fields(strings.ToLower(s.AttrOr("rel", "")))
+		href := resolveAgainst(baseURL, s.AttrOr("href", ""))
+		if href == "" {
+			return
+		}
+
+		for _, rel := range rels {
+			switch rel {
+			case "canonical":
+				if meta.Canonical == "" {
+					meta.Canonical = href
+				}
+			case "alternate":
+				if lang, ok := s.Attr("hreflang"); ok {
+					meta.Hreflang = append(meta.Hreflang, HreflangLink{
+						Lang: strings.ToLower(strings.TrimSpace(lang)),
+						URL:  href,
+					})
+				}
+			}
+		}
+	})
+
+	doc.Find("h1, h2, h3, h4, h5, h6").Each(func(_ int, s *goquery.Selection) {
+		level := int(goquery.NodeName(s)[1] - '0')
+		meta.Headings = append(meta.Headings, Heading{Level: level, Text: collapseWhitespace(s.Text())})
+	})
+
+	return meta
+}
+
+// resolveAgainst resolves a possibly-relative reference against base,
+// returning "" when it can't be parsed
+func resolveAgainst(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(parsed).String()
+}
@@ -0,0 +1,106 @@
+package crawler
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestGetSEOMetadataFromHTML(t *testing.T) {
+	inputURL := "https://blog.boot.dev/posts/go"
+	inputBody := `<!DOCTYPE html>
+<html lang="en-US">
+<head>
+	<title>  Learn   Go  </title>
+	<meta name="Description" content="A guide to Go.">
+	<meta name="robots" content="noindex, follow">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<meta property="og:title" content="Learn Go">
+	<meta property="og:image" content="https://blog.boot.dev/og.png">
+	<meta name="twitter:card" content="summary_large_image">
+	<link rel="canonical" href="/posts/go">
+	<link rel="alternate" hreflang="en" href="https://blog.boot.dev/posts/go">
+	<link rel="alternate" hreflang="DE" href="/de/posts/go">
+	<link rel="stylesheet" href="/style.css">
+</head>
+<body>
+	<h1>Learn Go</h1>
+	<h3>Skipped a level</h3>
+	<h2>Setup <em>first</em></h2>
+</body>
+</html>`
+
+	baseURL, err := url.Parse(inputURL)
+	if err != nil {
+		t.Fatalf("couldn't parse input URL: %v", err)
+	}
+
+	actual, err := getSEOMetadataFromHTML(inputBody, baseURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := seoMetadata{
+		Title:           "Learn Go",
+		MetaDescription: "A guide to Go.",
+		MetaRobots:      "noindex, follow",
+		Canonical:       "https://blog.boot.dev/posts/go",
+		Hreflang: []HreflangLink{
+			{Lang: "en", URL: "https://blog.boot.dev/posts/go"},
+			{Lang: "de", URL: "https://blog.boot.dev/de/posts/go"},
+		},
+		OpenGraph: map[string]string{
+			"og:title": "Learn Go",
+			"og:image": "https://blog.boot.dev/og.png",
+		},
+		TwitterCard: map[string]string{"twitter:card": "summary_large_image"},
+		Viewport:    "width=device-width, initial-scale=1",
+		Lang:        "en-US",
+		Headings: []Heading{
+			{Level: 1, Text: "Learn Go"},
+			{Level: 3, Text: "Skipped a level"},
+			{Level: 2, Text: "Setup first"},
+		},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestGetSEOMetadataFromHTMLEmpty(t *testing.T) {
+	baseURL, _ := url.Parse("https://blog.boot.dev")
+
+	actual, err := getSEOMetadataFromHTML("<html><body><p>Nothing here</p></body></html>", baseURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if actual.Title != "" || actual.Canonical != "" || len(actual.Headings) != 0 || len(actual.OpenGraph) != 0 {
+		t.Errorf("expected empty metadata, got %+v", actual)
+	}
+}
+
+func TestMetadataReportValues(t *testing.T) {
+	pageData := PageData{
+		Headings:  []Heading{{Level: 1, Text: "Title"}, {Level: 2, Text: "Sub"}},
+		Hreflang:  []HreflangLink{{Lang: "en", URL: "https://example.com"}},
+		OpenGraph: map[string]string{"og:type": "article", "og:title": "T"},
+	}
+
+	columns, err := lookupColumns([]string{"headings", "hreflang", "open_graph"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := [][]string{
+		{"h1: Title", "h2: Sub"},
+		{"en=https://example.com"},
+		{"og:title=T", "og:type=article"},
+	}
+	for i, col := range columns {
+		if actual := col.values(pageData); !reflect.DeepEqual(actual, expected[i]) {
+			t.Errorf("%s: expected %v, got %v", col.Name, expected[i], actual)
+		}
+	}
+}
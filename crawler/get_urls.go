@@ -0,0 +1,146 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Link is a single <a href> on a page, with the context it appeared in
+type Link struct {
+	URL        string   // Absolute URL the link points to
+	AnchorText string   // Visible text, or the alt text of a linked image
+	Rel        []string // rel tokens such as nofollow, ugc or sponsored
+	Target     string   // target attribute, e.g. _blank
+	Title      string   // title attribute
+	Position   string   // Closest page region: nav, header, footer, aside, main or body
+	Kind       LinkKind // Scheme and likely resource type, see classifyLink
+}
+
+// HasRel reports whether the link carries the given rel token
+func (link Link) HasRel(value string) bool {
+	for _, rel := range link.Rel {
+		if rel == value {
+			return true
+		}
+	}
+	return false
+}
+
+func getURLsFromHTML(htmlBody string, baseURL *url.URL) ([]string, error) {
+	links, err := getLinksFromHTML(htmlBody, baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return linkURLs(links), nil
+}
+
+// linkURLs flattens link records into their URLs, leaving out links that
+// don't point at an http(s) resource (mailto:, tel:, javascript:, #fragment, ...)
+func linkURLs(links []Link) []string {
+	var urls []string
+	for _, link := range links {
+		if link.Kind.isWeb() {
+			urls = append(urls, link.URL)
+		}
+	}
+	return urls
+}
+
+// getLinksFromHTML returns a record for every <a href> on the page, in document order,
+// including non-web links such as mailto: so they can be counted
+func getLinksFromHTML(htmlBody string, baseURL *url.URL) ([]Link, error) {
+	// Parse HTML
+	doc, err := parseHTML(htmlBody)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse HTML: %w", err)
+	}
+
+	return linksFromDocument(doc, baseURL), nil
+}
+
+func linksFromDocument(doc *goquery.Document, baseURL *url.URL) []Link {
+	var links []Link
+
+	// Resolve links against the document base
+	baseURL = documentBaseURL(doc, baseURL)
+
+	// Find all <a> tags with href attribute
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href, exists := s.Attr("href")
+		if !exists {
+			return
+		}
+
+		// Parse the href (could be relative or absolute)
+		parsedHref, err := url.Parse(href)
+		if err != nil {
+			return // Skip invalid URLs
+		}
+
+		// Resolve relative URLs to absolute
+		absoluteURL := baseURL.ResolveReference(parsedHref)
+
+		// Fall back to image alt text for image-only links
+		anchorText := collapseWhitespace(s.Text())
+		if anchorText == "" {
+			anchorText = collapseWhitespace(s.Find("img[alt]").First().AttrOr("alt", ""))
+		}
+
+		links = append(links, Link{
+			URL:        absoluteURL.String(),
+			AnchorText: anchorText,
+			Rel:        strings.Fields(strings.ToLower(s.AttrOr("rel", ""))),
+			Target:     s.AttrOr("target", ""),
+			Title:      s.AttrOr("title", ""),
+			Position:   linkPosition(s),
+			Kind:       classifyLink(href, absoluteURL, hasAttr(s, "download")),
+		})
+	})
+
+	return links
+}
+
+// hasAttr reports whether the element carries an attribute, even an empty one
+func hasAttr(s *goquery.Selection, name string) bool {
+	_, exists := s.Attr(name)
+	return exists
+}
+
+// landmarkRoles maps ARIA landmark roles onto the equivalent HTML5 elements
+var landmarkRoles = map[string]string{
+	"navigation":    "nav",
+	"banner":        "header",
+	"contentinfo":   "footer",
+	"complementary": "aside",
+	"main":          "main",
+}
+
+// linkPosition finds the closest landmark region containing the selection
+func linkPosition(s *goquery.Selection) string {
+	position := "body"
+
+	s.Parents().EachWithBreak(func(_ int, parent *goquery.Selection) bool {
+		if region, ok := landmarkRoles[parent.AttrOr("role", "")]; ok {
+			position = region
+			return false
+		}
+
+		switch tag := goquery.NodeName(parent); tag {
+		case "nav", "header", "footer", "aside", "main":
+			position = tag
+			return false
+		}
+		return true
+	})
+
+	return position
+}
+
+// collapseWhitespace trims text and squashes internal runs of whitespace
+func collapseWhitespace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
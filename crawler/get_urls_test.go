@@ -1,4 +1,4 @@
-package main
+package crawler
 
 import (
 	"net/url"
@@ -239,3 +239,127 @@ func TestGetImagesFromHTMLEmpty(t *testing.T) {
 		t.Errorf("expected empty slice, got %v", actual)
 	}
 }
+
+// ============================================
+// Tests for getLinksFromHTML
+// ============================================
+
+func TestGetLinksFromHTMLAttributes(t *testing.T) {
+	inputURL := "https://blog.boot.dev"
+	inputBody := `<html><body>
+		<nav><a href="/about">About   us</a></nav>
+		<main>
+			<p><a href="https://other.com" rel="NoFollow sponsored" target="_blank" title="Partner">Partner site</a></p>
+			<a href="/logo"><img src="/logo.png" alt="Logo"></a>
+		</main>
+		<div role="contentinfo"><a href="/terms">Terms</a></div>
+		<a href="/loose">Loose</a>
+	</body></html>`
+
+	baseURL, err := url.Parse(inputURL)
+	if err != nil {
+		t.Errorf("couldn't parse input URL: %v", err)
+		return
+	}
+
+	actual, err := getLinksFromHTML(inputBody, baseURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Link{
+		{URL: "https://blog.boot.dev/about", AnchorText: "About us", Rel: []string{}, Position: "nav", Kind: LinkPage},
+		{
+			URL:        "https://other.com",
+			AnchorText: "Partner site",
+			Rel:        []string{"nofollow", "sponsored"},
+			Target:     "_blank",
+			Title:      "Partner",
+			Position:   "main",
+			Kind:       LinkPage,
+		},
+		{URL: "https://blog.boot.dev/logo", AnchorText: "Logo", Rel: []string{}, Position: "main", Kind: LinkPage},
+		{URL: "https://blog.boot.dev/terms", AnchorText: "Terms", Rel: []string{}, Position: "footer", Kind: LinkPage},
+		{URL: "https://blog.boot.dev/loose", AnchorText: "Loose", Rel: []string{}, Position: "body", Kind: LinkPage},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+
+	if !actual[1].HasRel("nofollow") || actual[0].HasRel("nofollow") {
+		t.Errorf("HasRel reported the wrong nofollow state")
+	}
+}
+
+func TestBaseHrefResolution(t *testing.T) {
+	inputBody := `<html><head><base href="/docs/v2/"><link rel="canonical" href="intro"></head><body>
+		<a href="setup">Setup</a>
+		<a href="/absolute">Absolute</a>
+		<img src="img/diagram.png">
+	</body></html>`
+
+	pageData := extractPageData(inputBody, "https://example.com/landing")
+
+	expectedLinks := []string{"https://example.com/docs/v2/setup", "https://example.com/absolute"}
+	if !reflect.DeepEqual(pageData.OutgoingLinks, expectedLinks) {
+		t.Errorf("expected links %v, got %v", expectedLinks, pageData.OutgoingLinks)
+	}
+	if expected := []string{"https://example.com/docs/v2/img/diagram.png"}; !reflect.DeepEqual(pageData.ImageURLs, expected) {
+		t.Errorf("expected images %v, got %v", expected, pageData.ImageURLs)
+	}
+	if expected := "https://example.com/docs/v2/intro"; pageData.Canonical != expected {
+		t.Errorf("expected canonical %s, got %s", expected, pageData.Canonical)
+	}
+}
+
+func TestGetImageCandidatesFromHTML(t *testing.T) {
+	inputBody := `<html><head><style>.hero { background-image: url("/img/hero.jpg"); color: red }</style></head><body>
+		<img src="/small.jpg" srcset="/small.jpg 1x, /large.jpg 2x">
+		<img src="data:image/gif;base64,R0lGOD" data-src="/lazy.jpg" data-srcset="/lazy-400.jpg 400w,/lazy-800.jpg 800w">
+		<picture>
+			<source srcset="/photo.avif" type="image/avif">
+			<img src="/photo.jpg">
+		</picture>
+		<img srcset="/img,comma.jpg 1x">
+		<div style="background: #fff url('banner.png') no-repeat"></div>
+	</body></html>`
+
+	baseURL, err := url.Parse("https://example.com/page/")
+	if err != nil {
+		t.Fatalf("couldn't parse input URL: %v", err)
+	}
+
+	actual, err := getImageCandidatesFromHTML(inputBody, baseURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []ImageCandidate{
+		{URL: "https://example.com/small.jpg", Source: "src"},
+		{URL: "https://example.com/small.jpg", Source: "srcset"},
+		{URL: "https://example.com/large.jpg", Source: "srcset"},
+		{URL: "https://example.com/lazy.jpg", Source: "data-src"},
+		{URL: "https://example.com/lazy-400.jpg", Source: "data-srcset"},
+		{URL: "https://example.com/lazy-800.jpg", Source: "data-srcset"},
+		{URL: "https://example.com/photo.avif", Source: "source srcset"},
+		{URL: "https://example.com/photo.jpg", Source: "src"},
+		{URL: "https://example.com/img,comma.jpg", Source: "srcset"},
+		{URL: "https://example.com/page/banner.png", Source: "style"},
+		{URL: "https://example.com/img/hero.jpg", Source: "css"},
+	}
+
+	// Element attributes are covered by the image audit tests
+	var sources []ImageCandidate
+	for _, image := range actual {
+		sources = append(sources, ImageCandidate{URL: image.URL, Source: image.Source})
+	}
+	if !reflect.DeepEqual(sources, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	// The flat list keeps each URL once
+	urls := imageCandidateURLs(actual)
+	if len(urls) != len(expected)-1 {
+		t.Errorf("expected %d distinct image URLs, got %v", len(expected)-1, urls)
+	}
+}
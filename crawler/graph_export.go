@@ -0,0 +1,325 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// graphFormat is a file format the link graph can be exported to
+type graphFormat string
+
+const (
+	graphFormatDOT     graphFormat = "dot"     // Graphviz
+	graphFormatGraphML graphFormat = "graphml" // yEd, Gephi, networkx
+	graphFormatGEXF    graphFormat = "gexf"    // Gephi
+)
+
+// graphAttribute is a node attribute included in every export format
+type graphAttribute struct {
+	Name  string
+	Type  string // GraphML/GEXF type: string, int, double or boolean
+	value func(node graphNode) string
+}
+
+// nodeAttributes lists the node attributes written by every exporter, in order
+var nodeAttributes = []graphAttribute{
+	{"url", "string", func(n graphNode) string { return n.URL }},
+	{"h1", "string", func(n graphNode) string { return n.H1 }},
+	{"depth", "int", func(n graphNode) string { return strconv.Itoa(n.Depth) }},
+	{"status", "int", func(n graphNode) string { return strconv.Itoa(n.StatusCode) }},
+	{"inbound", "int", func(n graphNode) string { return strconv.Itoa(n.Inbound) }},
+	{"outbound", "int", func(n graphNode) string { return strconv.Itoa(n.Outbound) }},
+	{"page_rank", "double", func(n graphNode) string { return formatScore(n.PageRank) }},
+	{"hub_score", "double", func(n graphNode) string { return formatScore(n.HubScore) }},
+	{"authority_score", "double", func(n graphNode) string { return formatScore(n.AuthorityScore) }},
+}
+
+// graphAttributes returns nodeAttributes followed by a string attribute
+// for each custom field in the graph
+func graphAttributes(graph *linkGraph) []graphAttribute {
+	attrs := append([]graphAttribute(nil), nodeAttributes...)
+	for _, name := range graph.Fields {
+		attrs = append(attrs, graphAttribute{name, "string", func(n graphNode) string {
+			return strings.Join(n.Fields[name], ";")
+		}})
+	}
+	return attrs
+}
+
+// formatScore formats a link score without trailing zeros
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
+
+// writeGraphFile exports the graph to filename in the given format
+func writeGraphFile(graph *linkGraph, filename string, format graphFormat) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("couldn't create file: %w", err)
+	}
+	defer file.Close()
+
+	buffered := bufio.NewWriter(file)
+
+	switch format {
+	case graphFormatDOT:
+		err = writeDOT(buffered, graph)
+	case graphFormatGraphML:
+		err = writeGraphML(buffered, graph)
+	case graphFormatGEXF:
+		err = writeGEXF(buffered, graph)
+	default:
+		err = fmt.Errorf("unknown graph format %q (expected dot, graphml or gexf)", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("couldn't write graph: %w", err)
+	}
+	return file.Close()
+}
+
+// writeDOT writes the graph in Graphviz DOT syntax
+func writeDOT(w io.Writer, graph *linkGraph) error {
+	var b strings.Builder
+
+	attrs := graphAttributes(graph)
+	b.WriteString("digraph linkscout {\n")
+	for _, node := range graph.Nodes {
+		fmt.Fprintf(&b, "  %s [label=%s", dotQuote(node.ID), dotQuote(node.ID))
+		for _, attr := range attrs {
+			fmt.Fprintf(&b, ", %s=%s", dotID(attr.Name), dotQuote(attr.value(node)))
+		}
+		b.WriteString("];\n")
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [weight=%d", dotQuote(edge.Source), dotQuote(edge.Target), edge.Weight)
+		if edge.Nofollow {
+			b.WriteString(", nofollow=true, style=dashed")
+		}
+		b.WriteString("];\n")
+	}
+	b.WriteString("}\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("couldn't write graph: %w", err)
+	}
+	return nil
+}
+
+// dotIDPattern matches the DOT identifiers that need no quoting
+var dotIDPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// dotID writes a name as a DOT identifier, quoting it when needed
+func dotID(name string) string {
+	if dotIDPattern.MatchString(name) {
+		return name
+	}
+	return dotQuote(name)
+}
+
+// dotQuote returns s as a double-quoted DOT ID
+func dotQuote(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + replacer.Replace(s) + `"`
+}
+
+// GraphML document structure
+type graphMLDoc struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// writeGraphML writes the graph as GraphML
+func writeGraphML(w io.Writer, graph *linkGraph) error {
+	doc := graphMLDoc{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Graph: graphMLGraph{ID: "linkscout", EdgeDefault: "directed"},
+	}
+
+	attrs := graphAttributes(graph)
+	for _, attr := range attrs {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: attr.Name, For: "node", AttrName: attr.Name, AttrType: attr.Type})
+	}
+	doc.Keys = append(doc.Keys,
+		graphMLKey{ID: "weight", For: "edge", AttrName: "weight", AttrType: "int"},
+		graphMLKey{ID: "nofollow", For: "edge", AttrName: "nofollow", AttrType: "boolean"},
+	)
+
+	for _, node := range graph.Nodes {
+		xmlNode := graphMLNode{ID: node.ID}
+		for _, attr := range attrs {
+			xmlNode.Data = append(xmlNode.Data, graphMLData{Key: attr.Name, Value: attr.value(node)})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, xmlNode)
+	}
+
+	for i, edge := range graph.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			ID:     "e" + strconv.Itoa(i),
+			Source: edge.Source,
+			Target: edge.Target,
+			Data: []graphMLData{
+				{Key: "weight", Value: strconv.Itoa(edge.Weight)},
+				{Key: "nofollow", Value: strconv.FormatBool(edge.Nofollow)},
+			},
+		})
+	}
+
+	return encodeXML(w, doc)
+}
+
+// GEXF document structure
+type gexfDoc struct {
+	XMLName xml.Name  `xml:"gexf"`
+	XMLNS   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+	Mode            string           `xml:"mode,attr"`
+	Attributes      []gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode       `xml:"nodes>node"`
+	Edges           []gexfEdge       `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfEdge struct {
+	ID        string         `xml:"id,attr"`
+	Source    string         `xml:"source,attr"`
+	Target    string         `xml:"target,attr"`
+	Weight    int            `xml:"weight,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// writeGEXF writes the graph as GEXF 1.3
+func writeGEXF(w io.Writer, graph *linkGraph) error {
+	attrs := graphAttributes(graph)
+	nodeAttrs := gexfAttributes{Class: "node"}
+	for _, attr := range attrs {
+		nodeAttrs.Attributes = append(nodeAttrs.Attributes, gexfAttribute{ID: attr.Name, Title: attr.Name, Type: gexfType(attr.Type)})
+	}
+	edgeAttrs := gexfAttributes{
+		Class:      "edge",
+		Attributes: []gexfAttribute{{ID: "nofollow", Title: "nofollow", Type: "boolean"}},
+	}
+
+	doc := gexfDoc{
+		XMLNS:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Graph: gexfGraph{
+			DefaultEdgeType: "directed",
+			Mode:            "static",
+			Attributes:      []gexfAttributes{nodeAttrs, edgeAttrs},
+		},
+	}
+
+	for _, node := range graph.Nodes {
+		xmlNode := gexfNode{ID: node.ID, Label: node.ID}
+		for _, attr := range attrs {
+			xmlNode.AttValues = append(xmlNode.AttValues, gexfAttValue{For: attr.Name, Value: attr.value(node)})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, xmlNode)
+	}
+
+	for i, edge := range graph.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{
+			ID:        strconv.Itoa(i),
+			Source:    edge.Source,
+			Target:    edge.Target,
+			Weight:    edge.Weight,
+			AttValues: []gexfAttValue{{For: "nofollow", Value: strconv.FormatBool(edge.Nofollow)}},
+		})
+	}
+
+	return encodeXML(w, doc)
+}
+
+// gexfType maps GraphML attribute types onto GEXF's names
+func gexfType(graphMLType string) string {
+	if graphMLType == "int" {
+		return "integer"
+	}
+	return graphMLType
+}
+
+// encodeXML writes an indented XML document with its declaration
+func encodeXML(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("couldn't write graph: %w", err)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("couldn't encode graph: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("couldn't write graph: %w", err)
+	}
+	return nil
+}
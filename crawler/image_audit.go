@@ -0,0 +1,252 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Image audit issue kinds
+const (
+	imageMissingAlt        = "missing_alt"        // An <img> using the image has no alt attribute
+	imageMissingDimensions = "missing_dimensions" // An <img> using the image lacks width or height, causing layout shift
+	imageBroken            = "broken"             // The probe failed or returned 4xx/5xx
+	imageOversized         = "oversized"          // The file is larger than MaxBytes
+)
+
+// imageAuditOptions tunes the image audit
+type imageAuditOptions struct {
+	Probe       bool  // Send a HEAD request to each unique image for status, type and size
+	MaxBytes    int64 // Size above which an image is oversized; zero means DefaultMaxImageBytes
+	Concurrency int   // Parallel probes; zero means 5
+
+	fetcher HeadFetcher // Sends the probes; required when Probe is set
+}
+
+// DefaultMaxImageBytes is the size above which the image audit flags an
+// image as oversized
+const DefaultMaxImageBytes = 200 * 1024
+
+// defaultProbeTimeout bounds each probe when ReportOptions.Fetch has no timeout
+const defaultProbeTimeout = 15 * time.Second
+
+// imageProbe is what a HEAD request revealed about an image
+type imageProbe struct {
+	StatusCode  int
+	ContentType string
+	Size        int64  // Content-Length, or -1 when the server didn't say
+	Err         string // Request error, if the probe failed outright
+}
+
+// imageAudit is one unique image and everything known about it across the site
+type imageAudit struct {
+	URL     string
+	Pages   []string // Pages using the image, sorted
+	Sources []string // Distinct attributes it was found in, sorted
+	Alt     string   // First non-empty alt text seen
+	Title   string   // First non-empty title seen
+	Width   string   // First width seen
+	Height  string   // First height seen
+	Loading string   // First loading attribute seen
+	Probe   *imageProbe
+	Issues  []string // Issue kinds, in the order of the constants above
+}
+
+// auditImages collects every unique image across the pages and flags
+// problems. With Probe set, each unique image is requested once.
+func auditImages(pages map[string]PageData, opts imageAuditOptions) []imageAudit {
+	if opts.MaxBytes == 0 {
+		opts.MaxBytes = DefaultMaxImageBytes
+	}
+	if opts.Concurrency == 0 {
+		opts.Concurrency = 5
+	}
+
+	audits := make(map[string]*imageAudit)
+	missingAlt := make(map[string]bool)
+	missingDimensions := make(map[string]bool)
+
+	for _, pageData := range sortPages(pages, SortByURL) {
+		for _, image := range pageData.Images {
+			audit, exists := audits[image.URL]
+			if !exists {
+				audit = &imageAudit{URL: image.URL}
+				audits[image.URL] = audit
+			}
+
+			if n := len(audit.Pages); n == 0 || audit.Pages[n-1] != pageData.URL {
+				audit.Pages = append(audit.Pages, pageData.URL)
+			}
+			if !containsString(audit.Sources, image.Source) {
+				audit.Sources = append(audit.Sources, image.Source)
+			}
+
+			if !image.IsImg {
+				continue // CSS backgrounds and <source> have no alt or dimensions
+			}
+			if !image.HasAlt {
+				missingAlt[image.URL] = true
+			}
+			if image.Width == "" || image.Height == "" {
+				missingDimensions[image.URL] = true
+			}
+			fillEmpty(&audit.Alt, image.Alt)
+			fillEmpty(&audit.Title, image.Title)
+			fillEmpty(&audit.Width, image.Width)
+			fillEmpty(&audit.Height, image.Height)
+			fillEmpty(&audit.Loading, image.Loading)
+		}
+	}
+
+	urls := make([]string, 0, len(audits))
+	for imageURL := range audits {
+		urls = append(urls, imageURL)
+	}
+	sort.Strings(urls)
+
+	var probes map[string]imageProbe
+	if opts.Probe {
+		probes = probeImages(opts.fetcher, urls, opts.Concurrency)
+	}
+
+	result := make([]imageAudit, 0, len(urls))
+	for _, imageURL := range urls {
+		audit := audits[imageURL]
+		sort.Strings(audit.Sources)
+
+		if missingAlt[imageURL] {
+			audit.Issues = append(audit.Issues, imageMissingAlt)
+		}
+		if missingDimensions[imageURL] {
+			audit.Issues = append(audit.Issues, imageMissingDimensions)
+		}
+		if probe, ok := probes[imageURL]; ok {
+			audit.Probe = &probe
+			if probe.Err != "" || probe.StatusCode >= 400 {
+				audit.Issues = append(audit.Issues, imageBroken)
+			}
+			if probe.Size > opts.MaxBytes {
+				audit.Issues = append(audit.Issues, imageOversized)
+			}
+		}
+
+		result = append(result, *audit)
+	}
+
+	return result
+}
+
+// fillEmpty sets *field to value unless it already holds something
+func fillEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
+// probeImages sends one HEAD request per URL, a few at a time, through
+// the fetcher
+func probeImages(fetcher HeadFetcher, urls []string, concurrency int) map[string]imageProbe {
+	probes := make(map[string]imageProbe, len(urls))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, concurrency)
+
+	for _, imageURL := range urls {
+		wg.Add(1)
+		go func(imageURL string) {
+			defer wg.Done()
+			semaphore <- struct{}{}        // Acquire semaphore
+			defer func() { <-semaphore }() // Release semaphore
+
+			probe := probeImage(fetcher, imageURL)
+
+			mu.Lock()
+			probes[imageURL] = probe
+			mu.Unlock()
+		}(imageURL)
+	}
+
+	wg.Wait()
+	return probes
+}
+
+// probeImage requests the image headers
+func probeImage(fetcher HeadFetcher, imageURL string) imageProbe {
+	resp, err := fetcher.Head(context.Background(), imageURL)
+	if err != nil {
+		return imageProbe{Size: -1, Err: err.Error()}
+	}
+	return imageProbe{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.ContentType,
+		Size:        contentLength(resp.Header),
+	}
+}
+
+// contentLength returns the Content-Length header, or -1 when it's missing
+func contentLength(header http.Header) int64 {
+	size, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil || size < 0 {
+		return -1
+	}
+	return size
+}
+
+// imagesHeader is the column layout of the image report
+var imagesHeader = []string{
+	"image_url", "issues", "page_count", "pages", "sources", "alt", "title",
+	"width", "height", "loading", "status_code", "content_type", "bytes",
+}
+
+// writeImagesCSV writes one row per unique image. The probe columns are
+// empty when images weren't probed, and bytes is empty when the size is unknown.
+func writeImagesCSV(audits []imageAudit, filename string, opts CSVOptions) error {
+	opts = opts.WithDefaults()
+
+	rows := make([][]string, len(audits))
+	for i, audit := range audits {
+		var status, contentType, size string
+		if audit.Probe != nil {
+			if audit.Probe.StatusCode != 0 {
+				status = strconv.Itoa(audit.Probe.StatusCode)
+			}
+			contentType = audit.Probe.ContentType
+			if audit.Probe.Size >= 0 {
+				size = strconv.FormatInt(audit.Probe.Size, 10)
+			}
+		}
+
+		rows[i] = []string{
+			audit.URL,
+			strings.Join(audit.Issues, opts.MultiSeparator),
+			strconv.Itoa(len(audit.Pages)),
+			strings.Join(audit.Pages, opts.MultiSeparator),
+			strings.Join(audit.Sources, opts.MultiSeparator),
+			audit.Alt,
+			audit.Title,
+			audit.Width,
+			audit.Height,
+			audit.Loading,
+			status,
+			contentType,
+			size,
+		}
+	}
+
+	return writeCSVRows(filename, opts, imagesHeader, rows)
+}
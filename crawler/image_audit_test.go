@@ -0,0 +1,223 @@
+package crawler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestImageCandidateAttributes(t *testing.T) {
+	inputBody := `<html><body>
+		<img src="/a.png" alt=" Team  photo " title="Us" width="640" height="480" loading="LAZY">
+		<img src="/b.png" alt="">
+		<img src="/c.png">
+	</body></html>`
+
+	pageData := extractPageData(inputBody, "https://example.com")
+
+	expected := []ImageCandidate{
+		{URL: "https://example.com/a.png", Source: "src", IsImg: true, HasAlt: true, Alt: "Team photo", Title: "Us", Width: "640", Height: "480", Loading: "lazy"},
+		{URL: "https://example.com/b.png", Source: "src", IsImg: true, HasAlt: true},
+		{URL: "https://example.com/c.png", Source: "src", IsImg: true},
+	}
+	if !reflect.DeepEqual(pageData.Images, expected) {
+		t.Errorf("expected %+v, got %+v", expected, pageData.Images)
+	}
+}
+
+func TestAuditImages(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.Method+" "+r.URL.Path]++
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/logo.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Header().Set("Content-Length", "1200")
+		case "/hero.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Header().Set("Content-Length", "900000")
+		case "/no-head.gif":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Content-Type", "image/gif")
+			w.Write([]byte("GIF89a"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	pages := map[string]PageData{
+		"example.com": {
+			URL: "https://example.com",
+			Images: []ImageCandidate{
+				{URL: server.URL + "/logo.png", Source: "src", IsImg: true, HasAlt: true, Alt: "Logo", Width: "100", Height: "40"},
+				{URL: server.URL + "/hero.jpg", Source: "css"},
+				{URL: server.URL + "/missing.png", Source: "src", IsImg: true, HasAlt: true, Alt: "Gone", Width: "1", Height: "1"},
+			},
+		},
+		"example.com/about": {
+			URL: "https://example.com/about",
+			Images: []ImageCandidate{
+				{URL: server.URL + "/logo.png", Source: "src", IsImg: true, Width: "100"},
+				{URL: server.URL + "/logo.png", Source: "srcset", IsImg: true, Width: "100"},
+				{URL: server.URL + "/no-head.gif", Source: "src", IsImg: true, HasAlt: true, Width: "1", Height: "1"},
+			},
+		},
+	}
+
+	fetcher, err := newHTTPFetcher(FetchOptions{})
+	if err != nil {
+		t.Fatalf("couldn't create fetcher: %v", err)
+	}
+	audits := auditImages(pages, imageAuditOptions{Probe: true, fetcher: fetcher})
+
+	issues := make(map[string][]string)
+	for _, audit := range audits {
+		issues[strings.TrimPrefix(audit.URL, server.URL)] = audit.Issues
+	}
+	expected := map[string][]string{
+		"/hero.jpg":    {imageOversized},
+		"/logo.png":    {imageMissingAlt, imageMissingDimensions},
+		"/missing.png": {imageBroken},
+		"/no-head.gif": nil,
+	}
+	if !reflect.DeepEqual(issues, expected) {
+		t.Errorf("expected issues %v, got %v", expected, issues)
+	}
+
+	// Every unique image is probed exactly once, however many pages use it
+	expectedRequests := map[string]int{
+		"HEAD /logo.png":    1,
+		"HEAD /hero.jpg":    1,
+		"HEAD /missing.png": 1,
+		"HEAD /no-head.gif": 1,
+		"GET /no-head.gif":  1,
+	}
+	if !reflect.DeepEqual(requests, expectedRequests) {
+		t.Errorf("expected requests %v, got %v", expectedRequests, requests)
+	}
+
+	logo := audits[1]
+	if logo.Alt != "Logo" || !reflect.DeepEqual(logo.Sources, []string{"src", "srcset"}) || len(logo.Pages) != 2 {
+		t.Errorf("expected the logo to merge both pages, got %+v", logo)
+	}
+
+	filename := filepath.Join(t.TempDir(), "images.csv")
+	if err := writeImagesCSV(audits, filename, CSVOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	report, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("couldn't read report: %v", err)
+	}
+	expectedRow := server.URL + "/logo.png,missing_alt;missing_dimensions,2,https://example.com;https://example.com/about,src;srcset,Logo,,100,40,,200,image/png,1200\n"
+	if !strings.Contains(string(report), expectedRow) {
+		t.Errorf("expected report to contain %q, got:\n%s", expectedRow, report)
+	}
+}
+
+func TestAuditImagesWithoutProbe(t *testing.T) {
+	pages := map[string]PageData{
+		"example.com": {
+			URL:    "https://example.com",
+			Images: []ImageCandidate{{URL: "https://example.com/a.png", Source: "src", IsImg: true}},
+		},
+	}
+
+	audits := auditImages(pages, imageAuditOptions{})
+	if len(audits) != 1 || audits[0].Probe != nil {
+		t.Fatalf("expected one unprobed image, got %+v", audits)
+	}
+	if !reflect.DeepEqual(audits[0].Issues, []string{imageMissingAlt, imageMissingDimensions}) {
+		t.Errorf("unexpected issues: %v", audits[0].Issues)
+	}
+}
+
+func TestAuditImagesUsesFetcherSettings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow.png" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		if r.Header.Get("X-Token") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Length", "5000")
+	}))
+	defer server.Close()
+
+	fetcher, err := newHTTPFetcher(FetchOptions{Timeout: 50 * time.Millisecond, Headers: map[string]string{"X-Token": "secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages := map[string]PageData{
+		"example.com": {
+			URL: "https://example.com",
+			Images: []ImageCandidate{
+				{URL: server.URL + "/fast.png", Source: "css"},
+				{URL: server.URL + "/slow.png", Source: "css"},
+			},
+		},
+	}
+
+	audits := auditImages(pages, imageAuditOptions{Probe: true, MaxBytes: 4000, fetcher: fetcher})
+	if len(audits) != 2 {
+		t.Fatalf("expected 2 images, got %+v", audits)
+	}
+
+	// The fetcher's headers reach the server, and MaxBytes sets the limit
+	if fast := audits[0]; !reflect.DeepEqual(fast.Issues, []string{imageOversized}) || fast.Probe.StatusCode != http.StatusOK {
+		t.Errorf("expected the fast image to be probed and oversized, got %+v", fast)
+	}
+	// The fetcher's timeout applies to probes
+	if slow := audits[1]; !reflect.DeepEqual(slow.Issues, []string{imageBroken}) || !strings.Contains(slow.Probe.Err, "Timeout") {
+		t.Errorf("expected the slow image to time out, got %+v", slow)
+	}
+}
+
+func TestWriteReportsProbesWithFetchOptions(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		w.Header().Set("Content-Type", "image/png")
+	}))
+	defer server.Close()
+
+	snapshot := Snapshot{
+		BaseURL: "https://example.com",
+		Pages: map[string]PageData{
+			"example.com": {URL: "https://example.com", StatusCode: 200, Images: []ImageCandidate{{URL: server.URL + "/logo.png", Source: "css"}}},
+		},
+	}
+
+	// Probes send the fetch options' headers
+	opts := ReportOptions{OutputDir: t.TempDir(), ProbeImages: true, Fetch: FetchOptions{UserAgent: "probe-test"}}
+	if err := WriteReports(snapshot, opts, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if userAgent != "probe-test" {
+		t.Errorf("expected the probe to send the configured User-Agent, got %q", userAgent)
+	}
+
+	// A fetcher that can't be built is an error, not a silent default
+	opts.Fetch.CookieFile = filepath.Join(t.TempDir(), "missing.txt")
+	if err := WriteReports(snapshot, opts, io.Discard); err == nil {
+		t.Errorf("expected an error for a missing cookies file")
+	}
+}
@@ -0,0 +1,172 @@
+package crawler
+
+import (
+	"net/url"
+	"sort"
+)
+
+// graphOptions controls how pages and links are turned into a graph
+type graphOptions struct {
+	CollapseQuery bool // Merge URLs that differ only in their query string into one node
+	DropSelfLoops bool // Leave out links from a page to itself
+
+	normalizer *urlNormalizer // Turns URLs into node IDs; nil means the default pipeline
+}
+
+// graphNode is a crawled page
+type graphNode struct {
+	ID         string // Normalized URL, unique within the graph
+	URL        string
+	H1         string
+	Depth      int
+	StatusCode int
+	Inbound    int        // Distinct pages linking here
+	Outbound   int        // Distinct crawled pages linked from here
+	Fields     PageFields // Custom fields set by extractors
+
+	// Link scores, filled in by applyLinkScores
+	PageRank       float64
+	HubScore       float64
+	AuthorityScore float64
+}
+
+// graphEdge is one or more links from Source to Target
+type graphEdge struct {
+	Source   string // Node ID
+	Target   string // Node ID
+	Weight   int    // Number of links between the two pages
+	Nofollow bool   // Every link between the two pages is rel=nofollow
+}
+
+// linkGraph is the internal link structure of a crawl. Nodes are sorted by
+// ID and edges by source then target, so exports are deterministic.
+type linkGraph struct {
+	Nodes  []graphNode
+	Edges  []graphEdge
+	Fields []string // Custom field names found on the nodes, sorted
+}
+
+// buildLinkGraph turns crawled pages into a graph. Only links between
+// crawled pages become edges; external links are left out.
+func buildLinkGraph(pages map[string]PageData, opts graphOptions) *linkGraph {
+	nodes := make(map[string]*graphNode)
+
+	// Pages sorted by URL so merged nodes always keep the same representative
+	sorted := sortPages(pages, SortByURL)
+	for _, pageData := range sorted {
+		id, ok := graphNodeID(pageData.URL, opts)
+		if !ok {
+			continue
+		}
+		if existing, exists := nodes[id]; exists && existing.Depth <= pageData.Depth {
+			continue
+		}
+		nodes[id] = &graphNode{
+			ID:         id,
+			URL:        pageData.URL,
+			H1:         pageData.H1,
+			Depth:      pageData.Depth,
+			StatusCode: pageData.StatusCode,
+			Fields:     pageData.Fields,
+		}
+	}
+
+	type edgeKey struct{ source, target string }
+	edges := make(map[edgeKey]*graphEdge)
+
+	for _, pageData := range sorted {
+		source, ok := graphNodeID(pageData.URL, opts)
+		if !ok {
+			continue
+		}
+
+		for _, link := range pageLinks(pageData) {
+			target, ok := graphNodeID(link.URL, opts)
+			if !ok || nodes[target] == nil {
+				continue
+			}
+			if opts.DropSelfLoops && source == target {
+				continue
+			}
+
+			key := edgeKey{source, target}
+			edge, exists := edges[key]
+			if !exists {
+				edge = &graphEdge{Source: source, Target: target, Nofollow: true}
+				edges[key] = edge
+			}
+			edge.Weight++
+			edge.Nofollow = edge.Nofollow && link.HasRel("nofollow")
+		}
+	}
+
+	graph := &linkGraph{Fields: sortedFieldNames(pages)}
+	for _, edge := range edges {
+		graph.Edges = append(graph.Edges, *edge)
+		if edge.Source != edge.Target {
+			nodes[edge.Source].Outbound++
+			nodes[edge.Target].Inbound++
+		}
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].Source != graph.Edges[j].Source {
+			return graph.Edges[i].Source < graph.Edges[j].Source
+		}
+		return graph.Edges[i].Target < graph.Edges[j].Target
+	})
+
+	for _, node := range nodes {
+		graph.Nodes = append(graph.Nodes, *node)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		return graph.Nodes[i].ID < graph.Nodes[j].ID
+	})
+
+	return graph
+}
+
+// pageLinks returns the http(s) links of a page, falling back to bare URLs
+// for pages that only have OutgoingLinks
+func pageLinks(pageData PageData) []Link {
+	records := pageData.Links
+	if len(records) == 0 {
+		for _, rawURL := range pageData.OutgoingLinks {
+			records = append(records, Link{URL: rawURL})
+		}
+	}
+
+	var links []Link
+	for _, link := range records {
+		if link.Kind == "" {
+			// Records built without extraction haven't been classified yet
+			parsedURL, err := url.Parse(link.URL)
+			if err != nil {
+				continue
+			}
+			link.Kind = classifyLink(link.URL, parsedURL, false)
+		}
+		if link.Kind.isWeb() {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// graphNodeID returns the node key for a URL
+func graphNodeID(rawURL string, opts graphOptions) (string, bool) {
+	if opts.CollapseQuery {
+		parsed, err := url.Parse(rawURL)
+		if err != nil {
+			return "", false
+		}
+		parsed.RawQuery = ""
+		parsed.ForceQuery = false
+		rawURL = parsed.String()
+	}
+
+	id, err := opts.normalizer.normalize(rawURL)
+	if err != nil {
+		return "", false
+	}
+	return id, true
+}
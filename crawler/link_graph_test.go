@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testGraphPages() map[string]PageData {
+	return map[string]PageData{
+		"example.com": {
+			URL:        "https://example.com",
+			H1:         "Home",
+			StatusCode: 200,
+			Links: []Link{
+				{URL: "https://example.com/a"},
+				{URL: "https://example.com/a"},
+				{URL: "https://example.com/b", Rel: []string{"nofollow"}},
+				{URL: "https://example.com"},
+				{URL: "https://other.com"},
+			},
+		},
+		"example.com/a": {
+			URL:           "https://example.com/a",
+			H1:            `Say "hi"`,
+			Depth:         1,
+			StatusCode:    200,
+			OutgoingLinks: []string{"https://example.com"},
+		},
+		"example.com/b": {
+			URL:        "https://example.com/b",
+			Depth:      1,
+			StatusCode: 404,
+		},
+	}
+}
+
+func TestBuildLinkGraph(t *testing.T) {
+	graph := buildLinkGraph(testGraphPages(), graphOptions{DropSelfLoops: true})
+
+	expectedEdges := []graphEdge{
+		{Source: "example.com", Target: "example.com/a", Weight: 2},
+		{Source: "example.com", Target: "example.com/b", Weight: 1, Nofollow: true},
+		{Source: "example.com/a", Target: "example.com", Weight: 1},
+	}
+	if !reflect.DeepEqual(graph.Edges, expectedEdges) {
+		t.Errorf("expected edges %+v, got %+v", expectedEdges, graph.Edges)
+	}
+
+	expectedNodes := []graphNode{
+		{ID: "example.com", URL: "https://example.com", H1: "Home", StatusCode: 200, Inbound: 1, Outbound: 2},
+		{ID: "example.com/a", URL: "https://example.com/a", H1: `Say "hi"`, Depth: 1, StatusCode: 200, Inbound: 1, Outbound: 1},
+		{ID: "example.com/b", URL: "https://example.com/b", Depth: 1, StatusCode: 404, Inbound: 1},
+	}
+	if !reflect.DeepEqual(graph.Nodes, expectedNodes) {
+		t.Errorf("expected nodes %+v, got %+v", expectedNodes, graph.Nodes)
+	}
+}
+
+func TestBuildLinkGraphKeepsSelfLoops(t *testing.T) {
+	graph := buildLinkGraph(testGraphPages(), graphOptions{})
+
+	if len(graph.Edges) != 4 {
+		t.Fatalf("expected 4 edges including the self-loop, got %d", len(graph.Edges))
+	}
+	if graph.Nodes[0].Inbound != 1 {
+		t.Errorf("self-loops must not count as inbound links, got %d", graph.Nodes[0].Inbound)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	graph := buildLinkGraph(testGraphPages(), graphOptions{DropSelfLoops: true})
+
+	var b strings.Builder
+	if err := writeDOT(&b, graph); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		"digraph linkscout {\n",
+		`"example.com/a" [label="example.com/a", url="https://example.com/a", h1="Say \"hi\"", depth="1"`,
+		`"example.com" -> "example.com/b" [weight=1, nofollow=true, style=dashed];`,
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected DOT output to contain %q, got:\n%s", expected, b.String())
+		}
+	}
+}
+
+func TestWriteGraphMLAndGEXFAreValidXML(t *testing.T) {
+	graph := buildLinkGraph(testGraphPages(), graphOptions{DropSelfLoops: true})
+
+	var graphML strings.Builder
+	if err := writeGraphML(&graphML, graph); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var parsedGraphML graphMLDoc
+	if err := xml.Unmarshal([]byte(graphML.String()), &parsedGraphML); err != nil {
+		t.Fatalf("GraphML is not valid XML: %v", err)
+	}
+	if len(parsedGraphML.Graph.Nodes) != 3 || len(parsedGraphML.Graph.Edges) != 3 {
+		t.Errorf("unexpected GraphML contents: %+v", parsedGraphML.Graph)
+	}
+
+	var gexf strings.Builder
+	if err := writeGEXF(&gexf, graph); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var parsedGEXF gexfDoc
+	if err := xml.Unmarshal([]byte(gexf.String()), &parsedGEXF); err != nil {
+		t.Fatalf("GEXF is not valid XML: %v", err)
+	}
+	if len(parsedGEXF.Graph.Nodes) != 3 || len(parsedGEXF.Graph.Edges) != 3 {
+		t.Errorf("unexpected GEXF contents: %+v", parsedGEXF.Graph)
+	}
+}
@@ -0,0 +1,105 @@
+package crawler
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// LinkKind classifies a link by scheme and the resource it likely points to
+type LinkKind string
+
+const (
+	LinkPage       LinkKind = "page"       // http(s) link that may be an HTML page
+	LinkDownload   LinkKind = "download"   // Document or archive, or an <a download> link
+	LinkImage      LinkKind = "image"      // Image file
+	LinkMedia      LinkKind = "media"      // Audio or video file
+	LinkFragment   LinkKind = "fragment"   // Jump within the same page, e.g. #top
+	LinkMailto     LinkKind = "mailto"     // Email address
+	LinkTel        LinkKind = "tel"        // Phone number
+	LinkJavaScript LinkKind = "javascript" // javascript: pseudo-URL
+	LinkData       LinkKind = "data"       // Inline data: URL
+	LinkOther      LinkKind = "other"      // Any other scheme, e.g. ftp: or sms:
+)
+
+// isWeb reports whether the link points at an http(s) resource
+func (kind LinkKind) isWeb() bool {
+	switch kind {
+	case LinkPage, LinkDownload, LinkImage, LinkMedia:
+		return true
+	}
+	return false
+}
+
+// resourceExtensions maps file extensions onto the kind of resource they name.
+// Extensions not listed here, such as .html or .php, are treated as pages.
+var resourceExtensions = map[string]LinkKind{
+	".pdf": LinkDownload, ".zip": LinkDownload, ".gz": LinkDownload, ".tgz": LinkDownload,
+	".tar": LinkDownload, ".rar": LinkDownload, ".7z": LinkDownload, ".exe": LinkDownload,
+	".dmg": LinkDownload, ".msi": LinkDownload, ".apk": LinkDownload, ".iso": LinkDownload,
+	".doc": LinkDownload, ".docx": LinkDownload, ".xls": LinkDownload, ".xlsx": LinkDownload,
+	".ppt": LinkDownload, ".pptx": LinkDownload, ".odt": LinkDownload, ".csv": LinkDownload,
+	".epub": LinkDownload,
+
+	".png": LinkImage, ".jpg": LinkImage, ".jpeg": LinkImage, ".gif": LinkImage,
+	".webp": LinkImage, ".svg": LinkImage, ".avif": LinkImage, ".ico": LinkImage,
+	".bmp": LinkImage, ".tif": LinkImage, ".tiff": LinkImage,
+
+	".mp3": LinkMedia, ".wav": LinkMedia, ".ogg": LinkMedia, ".m4a": LinkMedia,
+	".mp4": LinkMedia, ".webm": LinkMedia, ".mov": LinkMedia, ".avi": LinkMedia,
+	".mkv": LinkMedia,
+}
+
+// classifyLink decides what kind of link an href is. href is the raw
+// attribute value, resolved the absolute URL it points to, and download
+// whether the <a> carries a download attribute.
+func classifyLink(href string, resolved *url.URL, download bool) LinkKind {
+	if strings.HasPrefix(strings.TrimSpace(href), "#") {
+		return LinkFragment
+	}
+
+	switch strings.ToLower(resolved.Scheme) {
+	case "http", "https":
+	case "mailto":
+		return LinkMailto
+	case "tel":
+		return LinkTel
+	case "javascript":
+		return LinkJavaScript
+	case "data":
+		return LinkData
+	default:
+		return LinkOther
+	}
+
+	if download {
+		return LinkDownload
+	}
+	if kind, ok := resourceExtensions[strings.ToLower(path.Ext(resolved.Path))]; ok {
+		return kind
+	}
+	return LinkPage
+}
+
+// linkCounts tallies the links that never enter the crawl frontier but are worth reporting
+type linkCounts struct {
+	Mailto   int
+	Tel      int
+	Download int
+}
+
+// countLinkKinds counts mailto, tel and download links
+func countLinkKinds(links []Link) linkCounts {
+	var counts linkCounts
+	for _, link := range links {
+		switch link.Kind {
+		case LinkMailto:
+			counts.Mailto++
+		case LinkTel:
+			counts.Tel++
+		case LinkDownload:
+			counts.Download++
+		}
+	}
+	return counts
+}
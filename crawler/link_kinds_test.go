@@ -0,0 +1,84 @@
+package crawler
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestClassifyLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		href     string
+		download bool
+		expected LinkKind
+	}{
+		{name: "relative page", href: "/about", expected: LinkPage},
+		{name: "html file", href: "/about.html", expected: LinkPage},
+		{name: "absolute page", href: "https://other.com/", expected: LinkPage},
+		{name: "fragment only", href: "#comments", expected: LinkFragment},
+		{name: "mailto", href: "mailto:hi@example.com", expected: LinkMailto},
+		{name: "tel", href: "tel:+15551234", expected: LinkTel},
+		{name: "javascript", href: "javascript:void(0)", expected: LinkJavaScript},
+		{name: "data", href: "data:text/plain,hello", expected: LinkData},
+		{name: "other scheme", href: "ftp://example.com/file", expected: LinkOther},
+		{name: "pdf", href: "/files/report.PDF", expected: LinkDownload},
+		{name: "zip with query", href: "/release.zip?v=2", expected: LinkDownload},
+		{name: "download attribute", href: "/export", download: true, expected: LinkDownload},
+		{name: "image", href: "/logo.png", expected: LinkImage},
+		{name: "video", href: "/intro.mp4", expected: LinkMedia},
+	}
+
+	baseURL, _ := url.Parse("https://example.com/blog/")
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			parsedHref, err := url.Parse(tc.href)
+			if err != nil {
+				t.Fatalf("Test %v - '%s' FAIL: couldn't parse href: %v", i, tc.name, err)
+			}
+			actual := classifyLink(tc.href, baseURL.ResolveReference(parsedHref), tc.download)
+			if actual != tc.expected {
+				t.Errorf("Test %v - %s FAIL: expected kind: %v, actual: %v", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNonCrawlableLinks(t *testing.T) {
+	inputBody := `<html><body>
+		<a href="/page">Page</a>
+		<a href="#top">Top</a>
+		<a href="mailto:hi@example.com">Email</a>
+		<a href="mailto:sales@example.com">Sales</a>
+		<a href="tel:+15551234">Call</a>
+		<a href="javascript:void(0)">Menu</a>
+		<a href="/guide.pdf">Guide</a>
+		<a href="/export" download>Export</a>
+		<a href="/photo.jpg">Photo</a>
+	</body></html>`
+
+	pageData := extractPageData(inputBody, "https://example.com")
+
+	expectedOutgoing := []string{
+		"https://example.com/page",
+		"https://example.com/guide.pdf",
+		"https://example.com/export",
+		"https://example.com/photo.jpg",
+	}
+	if !reflect.DeepEqual(pageData.OutgoingLinks, expectedOutgoing) {
+		t.Errorf("expected outgoing links %v, got %v", expectedOutgoing, pageData.OutgoingLinks)
+	}
+
+	if pageData.MailtoLinks != 2 || pageData.TelLinks != 1 || pageData.DownloadLinks != 2 {
+		t.Errorf("expected 2 mailto, 1 tel and 2 download links, got %d, %d and %d",
+			pageData.MailtoLinks, pageData.TelLinks, pageData.DownloadLinks)
+	}
+
+	// Only the HTML candidate enters the frontier, whatever the robots policy
+	for _, policy := range []RobotsPolicy{RobotsRespect, RobotsIgnore} {
+		cfg := &config{robotsPolicy: policy}
+		if actual := cfg.linksToFollow(pageData); !reflect.DeepEqual(actual, []string{"https://example.com/page"}) {
+			t.Errorf("%s: expected only the page link to be followed, got %v", policy, actual)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package crawler
+
+import (
+	"fmt"
+	"math"
+)
+
+// NofollowMode decides how rel=nofollow edges take part in link scoring
+type NofollowMode string
+
+const (
+	NofollowIgnore    NofollowMode = "ignore"    // Drop nofollow edges; equity goes to the followed links
+	NofollowEvaporate NofollowMode = "evaporate" // Nofollow edges take their share of equity, which is lost
+	NofollowFollow    NofollowMode = "follow"    // Treat nofollow edges like any other link
+)
+
+// ParseNofollowMode converts a user-supplied mode name into a NofollowMode
+func ParseNofollowMode(raw string) (NofollowMode, error) {
+	switch mode := NofollowMode(raw); mode {
+	case "":
+		return NofollowIgnore, nil
+	case NofollowIgnore, NofollowEvaporate, NofollowFollow:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown nofollow mode %q (expected ignore, evaporate or follow)", raw)
+	}
+}
+
+// PageRankOptions tunes the PageRank and HITS iterations
+type PageRankOptions struct {
+	Damping       float64      // Probability of following a link, above 0 and below 1; WithDefaults sets 0.85
+	Tolerance     float64      // Stop once the total score change drops below this; zero means 1e-6
+	MaxIterations int          // Upper bound on iterations; zero means 100
+	Nofollow      NofollowMode // Empty means NofollowIgnore
+}
+
+// Validate checks that the options are in range. A zero damping factor
+// is rejected, so call WithDefaults first to fill in an unset one.
+func (opts PageRankOptions) Validate() error {
+	if opts.Damping <= 0 || opts.Damping >= 1 {
+		return fmt.Errorf("damping factor must be between 0 and 1, exclusive, got %v", opts.Damping)
+	}
+	if opts.Tolerance < 0 {
+		return fmt.Errorf("tolerance must not be negative")
+	}
+	if opts.MaxIterations < 0 {
+		return fmt.Errorf("iterations must not be negative")
+	}
+	_, err := ParseNofollowMode(string(opts.Nofollow))
+	return err
+}
+
+// WithDefaults fills in any unset options
+func (opts PageRankOptions) WithDefaults() PageRankOptions {
+	if opts.Damping == 0 {
+		opts.Damping = 0.85
+	}
+	if opts.Tolerance == 0 {
+		opts.Tolerance = 1e-6
+	}
+	if opts.MaxIterations == 0 {
+		opts.MaxIterations = 100
+	}
+	if opts.Nofollow == "" {
+		opts.Nofollow = NofollowIgnore
+	}
+	return opts
+}
+
+// hitsScore is a page's HITS hub and authority score
+type hitsScore struct {
+	Hub       float64
+	Authority float64
+}
+
+// scoredEdges returns, per source node index, the target node indexes that pass equity.
+// With NofollowEvaporate, lost counts the nofollow edges of each source.
+func scoredEdges(graph *linkGraph, mode NofollowMode) (out [][]int, lost []int) {
+	index := make(map[string]int, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		index[node.ID] = i
+	}
+
+	out = make([][]int, len(graph.Nodes))
+	lost = make([]int, len(graph.Nodes))
+	for _, edge := range graph.Edges {
+		source, target := index[edge.Source], index[edge.Target]
+		if edge.Nofollow && mode != NofollowFollow {
+			if mode == NofollowEvaporate {
+				lost[source]++
+			}
+			continue
+		}
+		out[source] = append(out[source], target)
+	}
+	return out, lost
+}
+
+// computePageRank returns the internal PageRank of every node, keyed by node ID.
+// Scores sum to 1 unless nofollow equity evaporates. Dangling pages (no followed
+// outbound links) spread their score evenly over the whole site.
+func computePageRank(graph *linkGraph, opts PageRankOptions) map[string]float64 {
+	opts = opts.WithDefaults()
+
+	n := len(graph.Nodes)
+	if n == 0 {
+		return map[string]float64{}
+	}
+
+	out, lost := scoredEdges(graph, opts.Nofollow)
+
+	rank := make([]float64, n)
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+
+	for iteration := 0; iteration < opts.MaxIterations; iteration++ {
+		next := make([]float64, n)
+
+		// Collect the score of dangling pages to spread evenly
+		dangling := 0.0
+		for i := range graph.Nodes {
+			if len(out[i]) == 0 && lost[i] == 0 {
+				dangling += rank[i]
+			}
+		}
+
+		base := (1-opts.Damping)/float64(n) + opts.Damping*dangling/float64(n)
+		for i := range next {
+			next[i] = base
+		}
+
+		for source, targets := range out {
+			degree := len(targets) + lost[source]
+			if degree == 0 {
+				continue
+			}
+			share := opts.Damping * rank[source] / float64(degree)
+			for _, target := range targets {
+				next[target] += share
+			}
+		}
+
+		delta := 0.0
+		for i := range rank {
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank = next
+
+		if delta < opts.Tolerance {
+			break
+		}
+	}
+
+	scores := make(map[string]float64, n)
+	for i, node := range graph.Nodes {
+		scores[node.ID] = rank[i]
+	}
+	return scores
+}
+
+// computeHITS returns the hub and authority score of every node, keyed by node ID.
+// Both vectors are normalized to unit length.
+func computeHITS(graph *linkGraph, opts PageRankOptions) map[string]hitsScore {
+	opts = opts.WithDefaults()
+
+	n := len(graph.Nodes)
+	if n == 0 {
+		return map[string]hitsScore{}
+	}
+
+	out, _ := scoredEdges(graph, opts.Nofollow)
+
+	hub := make([]float64, n)
+	authority := make([]float64, n)
+	for i := range hub {
+		hub[i] = 1
+		authority[i] = 1
+	}
+
+	for iteration := 0; iteration < opts.MaxIterations; iteration++ {
+		// Authority: sum of the hub scores of pages linking in
+		nextAuthority := make([]float64, n)
+		for source, targets := range out {
+			for _, target := range targets {
+				nextAuthority[target] += hub[source]
+			}
+		}
+		normalizeVector(nextAuthority)
+
+		// Hub: sum of the authority scores of pages linked to
+		nextHub := make([]float64, n)
+		for source, targets := range out {
+			for _, target := range targets {
+				nextHub[source] += nextAuthority[target]
+			}
+		}
+		normalizeVector(nextHub)
+
+		delta := 0.0
+		for i := range hub {
+			delta += math.Abs(nextHub[i]-hub[i]) + math.Abs(nextAuthority[i]-authority[i])
+		}
+		hub, authority = nextHub, nextAuthority
+
+		if delta < opts.Tolerance {
+			break
+		}
+	}
+
+	scores := make(map[string]hitsScore, n)
+	for i, node := range graph.Nodes {
+		scores[node.ID] = hitsScore{Hub: hub[i], Authority: authority[i]}
+	}
+	return scores
+}
+
+// normalizeVector scales v to unit length in place (no-op for the zero vector)
+func normalizeVector(v []float64) {
+	sum := 0.0
+	for _, x := range v {
+		sum += x * x
+	}
+	if sum == 0 {
+		return
+	}
+
+	norm := math.Sqrt(sum)
+	for i := range v {
+		v[i] /= norm
+	}
+}
+
+// applyLinkScores computes PageRank and HITS over the graph and stores the
+// scores on both the graph nodes and the matching pages, so every report
+// can include them. Scores are rounded to keep reports readable and stable.
+func applyLinkScores(pages map[string]PageData, graph *linkGraph, graphOpts graphOptions, opts PageRankOptions) {
+	pageRank := computePageRank(graph, opts)
+	hits := computeHITS(graph, opts)
+
+	for i, node := range graph.Nodes {
+		graph.Nodes[i].PageRank = roundScore(pageRank[node.ID])
+		graph.Nodes[i].HubScore = roundScore(hits[node.ID].Hub)
+		graph.Nodes[i].AuthorityScore = roundScore(hits[node.ID].Authority)
+	}
+
+	for key, pageData := range pages {
+		id, ok := graphNodeID(pageData.URL, graphOpts)
+		if !ok {
+			continue
+		}
+		pageData.PageRank = roundScore(pageRank[id])
+		pageData.HubScore = roundScore(hits[id].Hub)
+		pageData.AuthorityScore = roundScore(hits[id].Authority)
+		pages[key] = pageData
+	}
+}
+
+// roundScore rounds a score to six decimal places
+func roundScore(score float64) float64 {
+	return math.Round(score*1e6) / 1e6
+}
@@ -0,0 +1,124 @@
+package crawler
+
+import (
+	"math"
+	"testing"
+)
+
+// graphFromEdges builds a graph directly from source→target pairs
+func graphFromEdges(ids []string, edges ...graphEdge) *linkGraph {
+	graph := &linkGraph{Edges: edges}
+	for _, id := range ids {
+		graph.Nodes = append(graph.Nodes, graphNode{ID: id})
+	}
+	return graph
+}
+
+func sumScores(scores map[string]float64) float64 {
+	total := 0.0
+	for _, score := range scores {
+		total += score
+	}
+	return total
+}
+
+func TestComputePageRankCycleIsUniform(t *testing.T) {
+	graph := graphFromEdges([]string{"a", "b", "c"},
+		graphEdge{Source: "a", Target: "b"},
+		graphEdge{Source: "b", Target: "c"},
+		graphEdge{Source: "c", Target: "a"},
+	)
+
+	scores := computePageRank(graph, PageRankOptions{})
+	for id, score := range scores {
+		if math.Abs(score-1.0/3) > 1e-6 {
+			t.Errorf("%s: expected 1/3, got %v", id, score)
+		}
+	}
+}
+
+func TestComputePageRankDanglingAndNofollow(t *testing.T) {
+	graph := graphFromEdges([]string{"a", "b", "c"},
+		graphEdge{Source: "a", Target: "b"},
+		graphEdge{Source: "c", Target: "b"},
+		graphEdge{Source: "c", Target: "a", Nofollow: true},
+	)
+
+	tests := []struct {
+		name     string
+		mode     NofollowMode
+		totalOne bool
+	}{
+		{name: "ignore", mode: NofollowIgnore, totalOne: true},
+		{name: "follow", mode: NofollowFollow, totalOne: true},
+		{name: "evaporate", mode: NofollowEvaporate, totalOne: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			scores := computePageRank(graph, PageRankOptions{Nofollow: tc.mode})
+
+			// b is linked from everyone and dangling, so it must rank highest
+			if scores["b"] <= scores["a"] || scores["b"] <= scores["c"] {
+				t.Errorf("expected b to rank highest, got %v", scores)
+			}
+
+			total := sumScores(scores)
+			if tc.totalOne && math.Abs(total-1) > 1e-6 {
+				t.Errorf("expected scores to sum to 1, got %v", total)
+			}
+			if !tc.totalOne && total >= 1-1e-6 {
+				t.Errorf("expected evaporated equity, got total %v", total)
+			}
+		})
+	}
+}
+
+func TestComputePageRankDamping(t *testing.T) {
+	graph := graphFromEdges([]string{"a", "b"}, graphEdge{Source: "a", Target: "b"})
+
+	// With (almost) no damping links carry no weight, so every page gets the same score
+	scores := computePageRank(graph, PageRankOptions{Damping: 1e-9})
+	if math.Abs(scores["a"]-scores["b"]) > 1e-6 {
+		t.Errorf("expected equal scores without damping, got %v", scores)
+	}
+}
+
+func TestComputeHITS(t *testing.T) {
+	graph := graphFromEdges([]string{"hub", "x", "y", "z"},
+		graphEdge{Source: "hub", Target: "x"},
+		graphEdge{Source: "hub", Target: "y"},
+		graphEdge{Source: "z", Target: "x"},
+	)
+
+	scores := computeHITS(graph, PageRankOptions{})
+
+	if scores["hub"].Hub <= scores["z"].Hub {
+		t.Errorf("expected hub to be the strongest hub, got %+v", scores)
+	}
+	if scores["x"].Authority <= scores["y"].Authority {
+		t.Errorf("expected x to be the strongest authority, got %+v", scores)
+	}
+	if scores["hub"].Authority != 0 {
+		t.Errorf("expected hub to have no authority, got %v", scores["hub"].Authority)
+	}
+}
+
+func TestApplyLinkScores(t *testing.T) {
+	pages := testGraphPages()
+	opts := graphOptions{DropSelfLoops: true}
+	graph := buildLinkGraph(pages, opts)
+
+	applyLinkScores(pages, graph, opts, PageRankOptions{})
+
+	for key, pageData := range pages {
+		if pageData.PageRank <= 0 {
+			t.Errorf("%s: expected a PageRank, got %v", key, pageData.PageRank)
+		}
+	}
+	for _, node := range graph.Nodes {
+		if node.PageRank != pages[node.ID].PageRank {
+			t.Errorf("%s: graph and page scores differ", node.ID)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package crawler
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Structural finding kinds
+const (
+	findingOrphan       = "orphan"        // No crawled page links here
+	findingWeaklyLinked = "weakly_linked" // Exactly one crawled page links here
+	findingDeadEnd      = "dead_end"      // No links to other crawled pages
+	findingDeep         = "deep"          // Shortest click path from the start page is too long
+)
+
+// structureOptions tunes the structural analysis
+type structureOptions struct {
+	MaxClickDepth int // Pages more clicks than this from the start page are deep; zero means 3
+}
+
+// structureFinding is one structural problem with one page
+type structureFinding struct {
+	URL              string
+	Finding          string
+	Inbound          int // Distinct crawled pages linking here
+	InternalOutbound int // Distinct crawled pages linked from here
+	ClickDepth       int // Shortest click path from the start page, -1 if unreachable
+	Detail           string
+}
+
+// analyzeLinkStructure flags orphan, weakly linked, dead-end and deep pages.
+// startURL is where the crawl began; it is never reported as an orphan.
+func analyzeLinkStructure(graph *linkGraph, startURL string, graphOpts graphOptions, opts structureOptions) []structureFinding {
+	if opts.MaxClickDepth == 0 {
+		opts.MaxClickDepth = 3
+	}
+
+	startID, _ := graphNodeID(startURL, graphOpts)
+	clickDepths := shortestClickDepths(graph, startID)
+
+	var findings []structureFinding
+	for _, node := range graph.Nodes {
+		depth, reachable := clickDepths[node.ID]
+		if !reachable {
+			depth = -1
+		}
+
+		add := func(kind, detail string) {
+			findings = append(findings, structureFinding{
+				URL:              node.URL,
+				Finding:          kind,
+				Inbound:          node.Inbound,
+				InternalOutbound: node.Outbound,
+				ClickDepth:       depth,
+				Detail:           detail,
+			})
+		}
+
+		switch {
+		case node.ID == startID:
+			// The start page needs no inbound links
+		case node.Inbound == 0:
+			add(findingOrphan, "no internal links point to this page")
+		case node.Inbound == 1:
+			add(findingWeaklyLinked, "only one internal page links here")
+		}
+
+		// Broken pages have no links by nature; they belong in a broken-link report
+		if node.Outbound == 0 && node.StatusCode < 400 {
+			add(findingDeadEnd, "page has no links to other internal pages")
+		}
+
+		if depth > opts.MaxClickDepth {
+			add(findingDeep, fmt.Sprintf("shortest path from the start page is %d clicks (limit %d)", depth, opts.MaxClickDepth))
+		}
+	}
+
+	return findings
+}
+
+// shortestClickDepths runs a breadth-first search from startID over every
+// edge, returning the number of clicks needed to reach each node
+func shortestClickDepths(graph *linkGraph, startID string) map[string]int {
+	adjacency := make(map[string][]string)
+	for _, edge := range graph.Edges {
+		adjacency[edge.Source] = append(adjacency[edge.Source], edge.Target)
+	}
+
+	depths := make(map[string]int)
+	hasStart := false
+	for _, node := range graph.Nodes {
+		if node.ID == startID {
+			hasStart = true
+			break
+		}
+	}
+	if !hasStart {
+		return depths
+	}
+
+	depths[startID] = 0
+	queue := []string{startID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, next := range adjacency[current] {
+			if _, seen := depths[next]; seen {
+				continue
+			}
+			depths[next] = depths[current] + 1
+			queue = append(queue, next)
+		}
+	}
+
+	return depths
+}
+
+// applyInboundCounts stores each page's inbound link count from the graph
+func applyInboundCounts(pages map[string]PageData, graph *linkGraph, graphOpts graphOptions) {
+	inbound := make(map[string]int, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		inbound[node.ID] = node.Inbound
+	}
+
+	for key, pageData := range pages {
+		id, ok := graphNodeID(pageData.URL, graphOpts)
+		if !ok {
+			continue
+		}
+		pageData.InboundLinks = inbound[id]
+		pages[key] = pageData
+	}
+}
+
+// structureHeader is the column layout of the structural findings report
+var structureHeader = []string{"page_url", "finding", "inbound_links", "internal_outbound_links", "click_depth", "detail"}
+
+// writeStructureCSV writes the structural findings, ordered by URL then finding
+func writeStructureCSV(findings []structureFinding, filename string, opts CSVOptions) error {
+	sorted := append([]structureFinding(nil), findings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].URL != sorted[j].URL {
+			return sorted[i].URL < sorted[j].URL
+		}
+		return sorted[i].Finding < sorted[j].Finding
+	})
+
+	rows := make([][]string, len(sorted))
+	for i, finding := range sorted {
+		rows[i] = []string{
+			finding.URL,
+			finding.Finding,
+			strconv.Itoa(finding.Inbound),
+			strconv.Itoa(finding.InternalOutbound),
+			strconv.Itoa(finding.ClickDepth),
+			finding.Detail,
+		}
+	}
+
+	return writeCSVRows(filename, opts, structureHeader, rows)
+}
@@ -0,0 +1,84 @@
+package crawler
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestAnalyzeLinkStructure(t *testing.T) {
+	page := func(path string, status int, links ...string) PageData {
+		pageData := PageData{URL: "https://example.com" + path, StatusCode: status}
+		for _, link := range links {
+			pageData.Links = append(pageData.Links, Link{URL: "https://example.com" + link})
+		}
+		return pageData
+	}
+
+	pages := map[string]PageData{
+		"example.com":        page("", 200, "/hub", "/leaf", "/broken"),
+		"example.com/hub":    page("/hub", 200, "/", "/leaf", "/one"),
+		"example.com/leaf":   page("/leaf", 200),
+		"example.com/broken": page("/broken", 404),
+		"example.com/one":    page("/one", 200, "/two"),
+		"example.com/two":    page("/two", 200, "/three", "/"),
+		"example.com/three":  page("/three", 200, "/"),
+		"example.com/orphan": page("/orphan", 200, "/"),
+	}
+
+	graphOpts := graphOptions{DropSelfLoops: true}
+	graph := buildLinkGraph(pages, graphOpts)
+	findings := analyzeLinkStructure(graph, "https://example.com/", graphOpts, structureOptions{MaxClickDepth: 3})
+
+	actual := map[string][]string{}
+	for _, finding := range findings {
+		actual[finding.Finding] = append(actual[finding.Finding], finding.URL)
+	}
+	for _, urls := range actual {
+		sort.Strings(urls)
+	}
+
+	expected := map[string][]string{
+		findingOrphan: {"https://example.com/orphan"},
+		findingWeaklyLinked: {
+			"https://example.com/broken",
+			"https://example.com/hub",
+			"https://example.com/one",
+			"https://example.com/three",
+			"https://example.com/two",
+		},
+		findingDeadEnd: {"https://example.com/leaf"},
+		findingDeep:    {"https://example.com/three"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	for _, finding := range findings {
+		if finding.URL == "https://example.com/orphan" && finding.ClickDepth != -1 {
+			t.Errorf("expected orphan to be unreachable, got click depth %d", finding.ClickDepth)
+		}
+	}
+
+	filename := filepath.Join(t.TempDir(), "structure.csv")
+	if err := writeStructureCSV(findings, filename, CSVOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filename); err != nil {
+		t.Errorf("expected report file: %v", err)
+	}
+}
+
+func TestApplyInboundCounts(t *testing.T) {
+	pages := testGraphPages()
+	graphOpts := graphOptions{DropSelfLoops: true}
+	applyInboundCounts(pages, buildLinkGraph(pages, graphOpts), graphOpts)
+
+	for key, expected := range map[string]int{"example.com": 1, "example.com/a": 1, "example.com/b": 1} {
+		if pages[key].InboundLinks != expected {
+			t.Errorf("%s: expected %d inbound links, got %d", key, expected, pages[key].InboundLinks)
+		}
+	}
+}
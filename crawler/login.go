@@ -0,0 +1,298 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+// LoginOptions configures a form login that runs before the crawl. The
+// session cookies it receives land in the fetcher's cookie jar.
+type LoginOptions struct {
+	URL             string            // Page with the login form
+	Fields          map[string]string // Form values, overriding the form's own
+	SecretFields    map[string]string // Form values read from "env:VAR" or "file:PATH"
+	SuccessURL      string            // Logged in when the form redirects here; may be relative to URL
+	SuccessSelector string            // Logged in when the resulting page contains this CSS selector
+}
+
+// Enabled reports whether a login is configured
+func (opts LoginOptions) Enabled() bool {
+	return opts.URL != ""
+}
+
+// Validate checks the options without reading any secrets
+func (opts LoginOptions) Validate() error {
+	if !opts.Enabled() {
+		return nil
+	}
+	if err := ValidateURL(opts.URL); err != nil {
+		return fmt.Errorf("invalid login URL: %w", err)
+	}
+	if opts.SuccessURL == "" && opts.SuccessSelector == "" {
+		return fmt.Errorf("login needs a success URL or a success selector")
+	}
+	if opts.SuccessSelector != "" {
+		if _, err := cascadia.ParseGroup(opts.SuccessSelector); err != nil {
+			return fmt.Errorf("invalid login success selector: %w", err)
+		}
+	}
+	for name, source := range opts.SecretFields {
+		if _, _, err := ParseSecretSource(source); err != nil {
+			return fmt.Errorf("login field %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// formLogin logs in through an HTML form and again whenever the session
+// is lost. Concurrent workers that notice a lost session share one login.
+type formLogin struct {
+	opts LoginOptions
+
+	mu         sync.Mutex
+	generation int // Number of successful logins so far
+}
+
+// loginForm is the form found on the login page
+type loginForm struct {
+	Action string
+	Method string
+	Values url.Values
+}
+
+// logIn fetches the login page, fills in its form and submits it, then
+// checks that the site considers us logged in
+func (l *formLogin) logIn(f *httpFetcher) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.logInLocked(f)
+}
+
+// relogIn logs in again unless another worker already did so since
+// generation was read
+func (l *formLogin) relogIn(f *httpFetcher, generation int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.generation != generation {
+		return nil
+	}
+	return l.logInLocked(f)
+}
+
+// currentGeneration returns the number of logins so far
+func (l *formLogin) currentGeneration() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.generation
+}
+
+func (l *formLogin) logInLocked(f *httpFetcher) error {
+	// Read the form, keeping hidden fields such as CSRF tokens
+	page, body, err := f.get(l.opts.URL)
+	if err != nil {
+		return fmt.Errorf("couldn't fetch login page: %w", err)
+	}
+	form, err := findLoginForm(body, page)
+	if err != nil {
+		return err
+	}
+
+	// Fill in the configured values; secrets are read now and never kept
+	for name, value := range l.opts.Fields {
+		form.Values.Set(name, value)
+	}
+	for name, source := range l.opts.SecretFields {
+		env, file, err := ParseSecretSource(source)
+		if err != nil {
+			return fmt.Errorf("login field %s: %w", name, err)
+		}
+		secret, err := readSecret(env, file)
+		if err != nil {
+			return fmt.Errorf("couldn't read login field %s: %w", name, err)
+		}
+		form.Values.Set(name, secret)
+	}
+
+	// Submit the form; the cookie jar keeps the session
+	resp, err := f.submit(form)
+	if err != nil {
+		return fmt.Errorf("couldn't submit login form: %w", err)
+	}
+	defer resp.Body.Close()
+
+	landed := resp.Request.URL
+	if err := l.checkSuccess(landed, resp); err != nil {
+		return err
+	}
+	l.generation++
+	return nil
+}
+
+// checkSuccess applies the configured success checks to the page the
+// login form led to
+func (l *formLogin) checkSuccess(landed *url.URL, resp *http.Response) error {
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("login failed: status code %d", resp.StatusCode)
+	}
+
+	if l.opts.SuccessURL != "" {
+		loginURL, err := url.Parse(l.opts.URL)
+		if err != nil {
+			return fmt.Errorf("invalid login URL: %w", err)
+		}
+		target, err := loginURL.Parse(l.opts.SuccessURL)
+		if err != nil {
+			return fmt.Errorf("invalid login success URL: %w", err)
+		}
+		if !samePage(landed, target) {
+			return fmt.Errorf("login failed: ended up on %s instead of %s", landed.Redacted(), target.Redacted())
+		}
+	}
+
+	if l.opts.SuccessSelector != "" {
+		doc, err := goquery.NewDocumentFromReader(resp.Body)
+		if err != nil {
+			return fmt.Errorf("couldn't parse page after login: %w", err)
+		}
+		if doc.Find(l.opts.SuccessSelector).Length() == 0 {
+			return fmt.Errorf("login failed: %q not found on %s", l.opts.SuccessSelector, landed.Redacted())
+		}
+	}
+	return nil
+}
+
+// loggedOut reports whether a fetched page shows the session was lost: the
+// site answered 401 or sent us to the login page. Fetching the login page
+// itself doesn't count.
+func (l *formLogin) loggedOut(requested string, result Response) bool {
+	loginURL, err := url.Parse(l.opts.URL)
+	if err != nil {
+		return false
+	}
+	if requestedURL, err := url.Parse(requested); err == nil && samePage(requestedURL, loginURL) {
+		return false
+	}
+	if result.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+	finalURL, err := url.Parse(result.FinalURL)
+	return err == nil && samePage(finalURL, loginURL)
+}
+
+// findLoginForm picks the form with a password field, or the only form on
+// the page, and collects its default values
+func findLoginForm(body string, page *url.URL) (loginForm, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return loginForm{}, fmt.Errorf("couldn't parse login page: %w", err)
+	}
+
+	forms := doc.Find("form")
+	selection := forms.FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return s.Find(`input[type="password" i]`).Length() > 0
+	}).First()
+	if selection.Length() == 0 {
+		if forms.Length() != 1 {
+			return loginForm{}, fmt.Errorf("couldn't find the login form on %s (%d forms, none with a password field)", page.Redacted(), forms.Length())
+		}
+		selection = forms
+	}
+
+	// The form posts to its action, resolved like any link, or back to the page
+	base := documentBaseURL(doc, page)
+	action := base
+	if raw, ok := selection.Attr("action"); ok && strings.TrimSpace(raw) != "" {
+		if action, err = base.Parse(strings.TrimSpace(raw)); err != nil {
+			return loginForm{}, fmt.Errorf("couldn't parse login form action: %w", err)
+		}
+	}
+	method := strings.ToUpper(strings.TrimSpace(selection.AttrOr("method", "")))
+	if method != http.MethodGet {
+		method = http.MethodPost
+	}
+
+	// Default values: inputs with a name, except unchecked boxes and buttons
+	values := url.Values{}
+	selection.Find("input[name], textarea[name], select[name]").Each(func(_ int, s *goquery.Selection) {
+		name := s.AttrOr("name", "")
+		switch strings.ToLower(s.AttrOr("type", "")) {
+		case "checkbox", "radio":
+			if _, checked := s.Attr("checked"); !checked {
+				return
+			}
+		case "submit", "button", "image", "reset", "file":
+			return
+		}
+		switch goquery.NodeName(s) {
+		case "textarea":
+			values.Add(name, s.Text())
+		case "select":
+			values.Add(name, s.Find("option[selected]").First().AttrOr("value", ""))
+		default:
+			values.Add(name, s.AttrOr("value", ""))
+		}
+	})
+
+	return loginForm{Action: action.String(), Method: method, Values: values}, nil
+}
+
+// get fetches a page and reads its body, following redirects
+func (f *httpFetcher) get(rawURL string) (*url.URL, string, error) {
+	req, err := f.newRequest(http.MethodGet, rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := f.do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, "", fmt.Errorf("status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response body: %w", err)
+	}
+	return resp.Request.URL, string(body), nil
+}
+
+// submit sends a form the way a browser would
+func (f *httpFetcher) submit(form loginForm) (*http.Response, error) {
+	if form.Method == http.MethodGet {
+		action, err := url.Parse(form.Action)
+		if err != nil {
+			return nil, err
+		}
+		action.RawQuery = form.Values.Encode()
+		req, err := f.newRequest(http.MethodGet, action.String())
+		if err != nil {
+			return nil, err
+		}
+		return f.do(req)
+	}
+
+	req, err := f.newRequest(http.MethodPost, form.Action)
+	if err != nil {
+		return nil, err
+	}
+	encoded := form.Values.Encode()
+	req.Body = io.NopCloser(strings.NewReader(encoded))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(encoded)), nil }
+	req.ContentLength = int64(len(encoded))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return f.do(req)
+}
+
+// samePage reports whether two URLs point at the same page, ignoring the
+// query and fragment
+func samePage(a, b *url.URL) bool {
+	return strings.EqualFold(a.Host, b.Host) && strings.TrimSuffix(a.Path, "/") == strings.TrimSuffix(b.Path, "/")
+}
@@ -0,0 +1,198 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// loginSite is a test site whose pages need a session from its login form.
+// expire logs every session out, as a server restart or timeout would.
+type loginSite struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	sessions map[string]bool
+	logins   int
+}
+
+func newLoginSite(t *testing.T) *loginSite {
+	site := &loginSite{sessions: make(map[string]bool)}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, `<html><body>
+<form method="post" action="/session">
+<input type="hidden" name="csrf" value="token-123">
+<input name="username"><input type="password" name="password">
+<input type="checkbox" name="remember" value="yes">
+<button type="submit" name="go">Log in</button>
+</form></body></html>`)
+			return
+		}
+	})
+	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Method != http.MethodPost || r.PostForm.Get("csrf") != "token-123" || r.PostForm.Get("username") != "deploy" ||
+			r.PostForm.Get("password") != "hunter2" || r.PostForm.Has("remember") || r.PostForm.Has("go") {
+			http.Redirect(w, r, "/login?failed=1", http.StatusSeeOther)
+			return
+		}
+
+		site.mu.Lock()
+		site.logins++
+		session := fmt.Sprintf("session-%d", site.logins)
+		site.sessions[session] = true
+		site.mu.Unlock()
+
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: session, Path: "/"})
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+	})
+
+	pages := map[string]string{
+		"/dashboard": `<div id="welcome">Hi</div><a href="/a">A</a><a href="/b">B</a>`,
+		"/a":         `<h1>A</h1><a href="/b">B</a>`,
+		"/b":         `<h1>B</h1><a href="/dashboard">Home</a>`,
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		cookie, err := r.Cookie("session")
+		site.mu.Lock()
+		valid := err == nil && site.sessions[cookie.Value]
+		site.mu.Unlock()
+		if !valid {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, "<html><body>%s</body></html>", body)
+	})
+
+	site.Server = httptest.NewServer(mux)
+	t.Cleanup(site.Close)
+	return site
+}
+
+func (s *loginSite) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = make(map[string]bool)
+}
+
+func (s *loginSite) loginCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins
+}
+
+func TestFormLogin(t *testing.T) {
+	t.Setenv("LINKSCOUT_TEST_PASSWORD", "hunter2")
+
+	tests := []struct {
+		name    string
+		login   LoginOptions
+		errText string
+	}{
+		{
+			name:  "redirect to success URL",
+			login: LoginOptions{Fields: map[string]string{"username": "deploy"}, SecretFields: map[string]string{"password": "env:LINKSCOUT_TEST_PASSWORD"}, SuccessURL: "/dashboard"},
+		},
+		{
+			name:  "success selector",
+			login: LoginOptions{Fields: map[string]string{"username": "deploy", "password": "hunter2"}, SuccessSelector: "#welcome"},
+		},
+		{
+			name:    "wrong password",
+			login:   LoginOptions{Fields: map[string]string{"username": "deploy", "password": "nope"}, SuccessURL: "/dashboard"},
+			errText: "login failed: ended up on",
+		},
+		{
+			name:    "missing secret",
+			login:   LoginOptions{Fields: map[string]string{"username": "deploy"}, SecretFields: map[string]string{"password": "env:LINKSCOUT_TEST_UNSET"}, SuccessSelector: "#welcome"},
+			errText: "LINKSCOUT_TEST_UNSET is not set",
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			site := newLoginSite(t)
+			tc.login.URL = site.URL + "/login"
+			fetcher, err := newHTTPFetcher(FetchOptions{Login: tc.login})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = fetcher.logIn(io.Discard)
+			if tc.errText != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errText) {
+					t.Errorf("Test %v - %s FAIL: expected error containing %q, got %v", i, tc.name, tc.errText, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+			}
+
+			// The session in the cookie jar opens the protected pages
+			result, err := fetcher.Fetch(context.Background(), site.URL+"/a")
+			if err != nil || result.StatusCode != http.StatusOK || !strings.HasSuffix(result.FinalURL, "/a") {
+				t.Errorf("Test %v - %s FAIL: expected /a after logging in, got %+v, %v", i, tc.name, result, err)
+			}
+		})
+	}
+}
+
+func TestFormLoginReauthenticates(t *testing.T) {
+	site := newLoginSite(t)
+	fetcher, err := newHTTPFetcher(FetchOptions{Login: LoginOptions{
+		URL:             site.URL + "/login",
+		Fields:          map[string]string{"username": "deploy", "password": "hunter2"},
+		SuccessSelector: "#welcome",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fetcher.logIn(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	// Workers that all find themselves logged out share a single login
+	site.expire()
+	var wg sync.WaitGroup
+	results := make([]Response, 4)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = fetcher.Fetch(context.Background(), site.URL+"/b")
+		}(i)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		if result.StatusCode != http.StatusOK || !strings.Contains(result.Body, "<h1>B</h1>") {
+			t.Errorf("worker %d: expected /b after logging in again, got %d %s", i, result.StatusCode, result.FinalURL)
+		}
+	}
+	if logins := site.loginCount(); logins != 2 {
+		t.Errorf("expected 2 logins, got %d", logins)
+	}
+
+	// Fetching the login page itself doesn't count as being logged out
+	if _, err := fetcher.Fetch(context.Background(), site.URL+"/login"); err != nil {
+		t.Fatal(err)
+	}
+	if logins := site.loginCount(); logins != 2 {
+		t.Errorf("expected the login page not to trigger a login, got %d logins", logins)
+	}
+}
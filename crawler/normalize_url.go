@@ -0,0 +1,400 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// SchemePolicy decides how the scheme appears in normalized URLs
+type SchemePolicy string
+
+const (
+	SchemeDrop  SchemePolicy = "drop"  // http and https pages share a key (the default)
+	SchemeKeep  SchemePolicy = "keep"  // http and https pages are different pages
+	SchemeHTTPS SchemePolicy = "https" // Keep the scheme, treating http as https
+)
+
+// SlashPolicy decides what happens to trailing slashes in the path
+type SlashPolicy string
+
+const (
+	SlashStrip SlashPolicy = "strip" // /path/ becomes /path (the default)
+	SlashKeep  SlashPolicy = "keep"  // /path and /path/ are different pages
+	SlashAdd   SlashPolicy = "add"   // /path becomes /path/, except for file-like paths such as /a.html
+)
+
+// IDNPolicy decides how internationalized host names are written
+type IDNPolicy string
+
+const (
+	IDNPunycode IDNPolicy = "punycode" // bücher.de becomes xn--bcher-kva.de (the default)
+	IDNUnicode  IDNPolicy = "unicode"  // xn--bcher-kva.de becomes bücher.de
+)
+
+// ParseSchemePolicy converts a user-supplied policy name into a SchemePolicy
+func ParseSchemePolicy(raw string) (SchemePolicy, error) {
+	switch policy := SchemePolicy(raw); policy {
+	case "":
+		return SchemeDrop, nil
+	case SchemeDrop, SchemeKeep, SchemeHTTPS:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown scheme policy %q (expected drop, keep or https)", raw)
+	}
+}
+
+// ParseSlashPolicy converts a user-supplied policy name into a SlashPolicy
+func ParseSlashPolicy(raw string) (SlashPolicy, error) {
+	switch policy := SlashPolicy(raw); policy {
+	case "":
+		return SlashStrip, nil
+	case SlashStrip, SlashKeep, SlashAdd:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown trailing slash policy %q (expected strip, keep or add)", raw)
+	}
+}
+
+// ParseIDNPolicy converts a user-supplied policy name into an IDNPolicy
+func ParseIDNPolicy(raw string) (IDNPolicy, error) {
+	switch policy := IDNPolicy(raw); policy {
+	case "":
+		return IDNPunycode, nil
+	case IDNPunycode, IDNUnicode:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown IDN policy %q (expected punycode or unicode)", raw)
+	}
+}
+
+// DefaultTrackingParams are stripped from queries unless NormalizeOptions
+// says otherwise. A trailing * matches any suffix.
+var DefaultTrackingParams = []string{"utm_*", "fbclid", "gclid"}
+
+// NormalizeOptions configures the URL normalization pipeline.
+// The zero value is the default pipeline with every step enabled.
+type NormalizeOptions struct {
+	Scheme          SchemePolicy `json:"scheme,omitempty"`            // Empty means SchemeDrop
+	TrailingSlash   SlashPolicy  `json:"trailing_slash,omitempty"`    // Empty means SlashStrip
+	IDN             IDNPolicy    `json:"idn,omitempty"`               // Empty means IDNPunycode
+	KeepDefaultPort bool         `json:"keep_default_port,omitempty"` // Keep :80 on http and :443 on https
+	KeepDotSegments bool         `json:"keep_dot_segments,omitempty"` // Don't resolve /a/./b/../c to /a/c
+	KeepEncoding    bool         `json:"keep_encoding,omitempty"`     // Don't normalize percent-encoding
+	KeepQueryOrder  bool         `json:"keep_query_order,omitempty"`  // Don't sort query parameters
+	DropQuery       bool         `json:"drop_query,omitempty"`        // Remove the whole query string
+	KeepFragment    bool         `json:"keep_fragment,omitempty"`     // Keep #fragments
+	TrackingParams  []string     `json:"tracking_params"`             // Query parameters to strip; nil means DefaultTrackingParams, empty keeps all
+}
+
+// normalizeStep is one stage of the pipeline, applied to a parsed URL in place
+type normalizeStep struct {
+	Name  string
+	apply func(u *url.URL)
+}
+
+// urlNormalizer turns URLs into the keys used to deduplicate pages
+type urlNormalizer struct {
+	opts  NormalizeOptions
+	steps []normalizeStep
+}
+
+// newURLNormalizer builds the pipeline for the given options, in the order
+// the steps must run: host and port first, then path, query and fragment
+func newURLNormalizer(opts NormalizeOptions) *urlNormalizer {
+	if opts.Scheme == "" {
+		opts.Scheme = SchemeDrop
+	}
+	if opts.TrailingSlash == "" {
+		opts.TrailingSlash = SlashStrip
+	}
+	if opts.IDN == "" {
+		opts.IDN = IDNPunycode
+	}
+	if opts.TrackingParams == nil {
+		opts.TrackingParams = DefaultTrackingParams
+	}
+
+	n := &urlNormalizer{opts: opts}
+	add := func(name string, apply func(u *url.URL)) {
+		n.steps = append(n.steps, normalizeStep{Name: name, apply: apply})
+	}
+
+	if opts.Scheme == SchemeHTTPS {
+		add("upgrade_scheme", upgradeScheme)
+	}
+	add("lowercase_host", lowercaseHost)
+	add("idn", func(u *url.URL) { convertIDN(u, opts.IDN) })
+	if !opts.KeepDefaultPort {
+		add("remove_default_port", removeDefaultPort)
+	}
+	if !opts.KeepDotSegments {
+		add("resolve_dot_segments", resolveDotSegments)
+	}
+	if !opts.KeepEncoding {
+		add("normalize_encoding", normalizeEncoding)
+	}
+	if opts.DropQuery {
+		add("drop_query", dropQuery)
+	} else {
+		if len(opts.TrackingParams) > 0 {
+			add("strip_tracking_params", func(u *url.URL) { stripTrackingParams(u, opts.TrackingParams) })
+		}
+		if !opts.KeepQueryOrder {
+			add("sort_query", sortQuery)
+		}
+	}
+	if !opts.KeepFragment {
+		add("remove_fragment", removeFragment)
+	}
+	add("trailing_slash", func(u *url.URL) { applySlashPolicy(u, opts.TrailingSlash) })
+
+	return n
+}
+
+// defaultNormalizer is the pipeline with every step enabled. It is never
+// changed; crawls with other options carry their own urlNormalizer.
+var defaultNormalizer = newURLNormalizer(NormalizeOptions{})
+
+// normalizeURL returns the key the default pipeline uses to deduplicate a
+// URL, e.g. "blog.boot.dev/path?page=2" for "https://BLOG.boot.dev/path/?page=2#top"
+func normalizeURL(inputURL string) (string, error) {
+	return defaultNormalizer.normalize(inputURL)
+}
+
+// normalize runs every step of the pipeline and formats the key. A nil
+// normalizer runs the default pipeline.
+func (n *urlNormalizer) normalize(inputURL string) (string, error) {
+	if n == nil {
+		n = defaultNormalizer
+	}
+	parsedURL, err := url.Parse(inputURL)
+	if err != nil {
+		return "", fmt.Errorf("couldn't parse URL: %w", err)
+	}
+
+	for _, step := range n.steps {
+		step.apply(parsedURL)
+	}
+
+	key := parsedURL.Host + parsedURL.EscapedPath()
+	if parsedURL.RawQuery != "" {
+		key += "?" + parsedURL.RawQuery
+	}
+	if parsedURL.Fragment != "" {
+		key += "#" + parsedURL.EscapedFragment()
+	}
+	if n.opts.Scheme != SchemeDrop && parsedURL.Scheme != "" {
+		key = parsedURL.Scheme + "://" + key
+	}
+
+	return key, nil
+}
+
+// upgradeScheme treats http URLs as https
+func upgradeScheme(u *url.URL) {
+	if u.Scheme == "http" {
+		u.Scheme = "https"
+	}
+}
+
+// lowercaseHost lowercases the scheme and host but never the path,
+// which is case-sensitive on most servers
+func lowercaseHost(u *url.URL) {
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+}
+
+// convertIDN writes internationalized host names as punycode or Unicode.
+// Hosts that fail conversion are left as they are.
+func convertIDN(u *url.URL, policy IDNPolicy) {
+	hostname, port := u.Hostname(), u.Port()
+	if hostname == "" || strings.Contains(hostname, ":") {
+		return // Empty or IPv6
+	}
+
+	var converted string
+	var err error
+	if policy == IDNUnicode {
+		converted, err = idna.Lookup.ToUnicode(hostname)
+	} else {
+		converted, err = idna.Lookup.ToASCII(hostname)
+	}
+	if err != nil {
+		return
+	}
+
+	u.Host = converted
+	if port != "" {
+		u.Host += ":" + port
+	}
+}
+
+// removeDefaultPort drops :80 from http and :443 from https URLs
+func removeDefaultPort(u *url.URL) {
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+}
+
+// resolveDotSegments removes "." and ".." path segments as described in RFC 3986
+func resolveDotSegments(u *url.URL) {
+	if !strings.Contains(u.Path, ".") {
+		return
+	}
+
+	segments := strings.Split(u.Path, "/")
+	var output []string
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch segment {
+		case ".":
+			if last {
+				output = append(output, "")
+			}
+		case "..":
+			if len(output) > 1 {
+				output = output[:len(output)-1]
+			}
+			if last {
+				output = append(output, "")
+			}
+		default:
+			output = append(output, segment)
+		}
+	}
+
+	path := strings.Join(output, "/")
+	if strings.HasPrefix(u.Path, "/") && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	u.Path = path
+	u.RawPath = ""
+}
+
+// normalizeEncoding decodes percent-encoded unreserved characters and
+// uppercases the hex digits of the escapes that remain, so /%7euser and
+// /~user are the same path
+func normalizeEncoding(u *url.URL) {
+	escaped := u.EscapedPath()
+
+	var b strings.Builder
+	for i := 0; i < len(escaped); i++ {
+		if escaped[i] != '%' || i+2 >= len(escaped) {
+			b.WriteByte(escaped[i])
+			continue
+		}
+		hex := strings.ToUpper(escaped[i+1 : i+3])
+		decoded, err := url.PathUnescape("%" + hex)
+		if err != nil {
+			b.WriteByte(escaped[i])
+			continue
+		}
+		if isUnreserved(decoded[0]) {
+			b.WriteString(decoded)
+		} else {
+			b.WriteString("%" + hex)
+		}
+		i += 2
+	}
+
+	path, err := url.PathUnescape(b.String())
+	if err != nil {
+		return
+	}
+	u.Path = path
+	u.RawPath = b.String()
+}
+
+// isUnreserved reports whether c never needs percent-encoding (RFC 3986 section 2.3)
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+// dropQuery removes the whole query string
+func dropQuery(u *url.URL) {
+	u.RawQuery = ""
+	u.ForceQuery = false
+}
+
+// stripTrackingParams removes query parameters matching any of the patterns
+func stripTrackingParams(u *url.URL, patterns []string) {
+	if u.RawQuery == "" {
+		return
+	}
+
+	var kept []string
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		name, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if !matchesParam(strings.ToLower(name), patterns) {
+			kept = append(kept, pair)
+		}
+	}
+	u.RawQuery = strings.Join(kept, "&")
+	u.ForceQuery = false
+}
+
+// matchesParam reports whether a parameter name matches any pattern
+func matchesParam(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if prefix, wildcard := strings.CutSuffix(pattern, "*"); wildcard {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// sortQuery orders query parameters by name. Repeated parameters keep
+// their relative order, since ?a=1&a=2 and ?a=2&a=1 may differ.
+func sortQuery(u *url.URL) {
+	if u.RawQuery == "" {
+		u.ForceQuery = false
+		return
+	}
+
+	pairs := strings.Split(u.RawQuery, "&")
+	sort.SliceStable(pairs, func(i, j int) bool {
+		nameI, _, _ := strings.Cut(pairs[i], "=")
+		nameJ, _, _ := strings.Cut(pairs[j], "=")
+		return nameI < nameJ
+	})
+	u.RawQuery = strings.Join(pairs, "&")
+}
+
+// removeFragment drops the #fragment, which never reaches the server
+func removeFragment(u *url.URL) {
+	u.Fragment = ""
+	u.RawFragment = ""
+}
+
+// applySlashPolicy strips or adds the trailing slash of the path
+func applySlashPolicy(u *url.URL, policy SlashPolicy) {
+	switch policy {
+	case SlashStrip:
+		u.Path = strings.TrimRight(u.Path, "/")
+		if u.RawPath != "" {
+			u.RawPath = strings.TrimRight(u.RawPath, "/")
+		}
+	case SlashAdd:
+		lastSegment := u.Path[strings.LastIndex(u.Path, "/")+1:]
+		if strings.HasSuffix(u.Path, "/") || strings.Contains(lastSegment, ".") {
+			return
+		}
+		u.Path += "/"
+		if u.RawPath != "" {
+			u.RawPath += "/"
+		}
+	}
+}
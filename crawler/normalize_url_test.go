@@ -0,0 +1,226 @@
+package crawler
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		inputURL string
+		expected string
+	}{
+		{
+			name:     "remove https scheme",
+			inputURL: "https://blog.boot.dev/path",
+			expected: "blog.boot.dev/path",
+		},
+		{
+			name:     "remove http scheme",
+			inputURL: "http://blog.boot.dev/path",
+			expected: "blog.boot.dev/path",
+		},
+		{
+			name:     "remove trailing slash",
+			inputURL: "https://blog.boot.dev/path/",
+			expected: "blog.boot.dev/path",
+		},
+		{
+			name:     "remove scheme and trailing slash",
+			inputURL: "http://blog.boot.dev/path/",
+			expected: "blog.boot.dev/path",
+		},
+		{
+			name:     "handle domain without path",
+			inputURL: "https://blog.boot.dev",
+			expected: "blog.boot.dev",
+		},
+		{
+			name:     "handle domain without path but with trailing slash",
+			inputURL: "https://blog.boot.dev/",
+			expected: "blog.boot.dev",
+		},
+		{
+			name:     "handle multiple trailing slashes",
+			inputURL: "https://blog.boot.dev/path///",
+			expected: "blog.boot.dev/path",
+		},
+		{
+			name:     "lowercase domain",
+			inputURL: "https://BLOG.boot.dev/path",
+			expected: "blog.boot.dev/path",
+		},
+		{
+			name:     "handle url with port",
+			inputURL: "http://blog.boot.dev:8080/path",
+			expected: "blog.boot.dev:8080/path",
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := normalizeURL(tc.inputURL)
+			if err != nil {
+				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+				return
+			}
+			if actual != tc.expected {
+				t.Errorf("Test %v - %s FAIL: expected URL: %v, actual: %v", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNormalizeSteps(t *testing.T) {
+	tests := []struct {
+		name     string
+		step     func(u *url.URL)
+		inputURL string
+		expected string
+	}{
+		{"upgrade http", upgradeScheme, "http://example.com/a", "https://example.com/a"},
+		{"upgrade leaves https", upgradeScheme, "https://example.com/a", "https://example.com/a"},
+		{"lowercase host", lowercaseHost, "HTTPS://Example.COM/a", "https://example.com/a"},
+		{"lowercase host keeps path case", lowercaseHost, "https://EXAMPLE.com/About/Team", "https://example.com/About/Team"},
+		{"idn to punycode", func(u *url.URL) { convertIDN(u, IDNPunycode) }, "https://bücher.de/a", "https://xn--bcher-kva.de/a"},
+		{"idn keeps port", func(u *url.URL) { convertIDN(u, IDNPunycode) }, "https://bücher.de:8080/a", "https://xn--bcher-kva.de:8080/a"},
+		{"idn to unicode", func(u *url.URL) { convertIDN(u, IDNUnicode) }, "https://xn--bcher-kva.de/a", "https://b%C3%BCcher.de/a"}, // String() escapes non-ASCII hosts
+		{"idn leaves ascii", func(u *url.URL) { convertIDN(u, IDNPunycode) }, "https://example.com/a", "https://example.com/a"},
+		{"remove :443 on https", removeDefaultPort, "https://example.com:443/a", "https://example.com/a"},
+		{"remove :80 on http", removeDefaultPort, "http://example.com:80/a", "http://example.com/a"},
+		{"keep :80 on https", removeDefaultPort, "https://example.com:80/a", "https://example.com:80/a"},
+		{"keep other ports", removeDefaultPort, "http://example.com:8080/a", "http://example.com:8080/a"},
+		{"resolve single dot", resolveDotSegments, "https://example.com/a/./b", "https://example.com/a/b"},
+		{"resolve double dot", resolveDotSegments, "https://example.com/a/b/../c", "https://example.com/a/c"},
+		{"resolve trailing double dot", resolveDotSegments, "https://example.com/a/b/..", "https://example.com/a/"},
+		{"double dot above root", resolveDotSegments, "https://example.com/../a", "https://example.com/a"},
+		{"dots inside names untouched", resolveDotSegments, "https://example.com/v1.2/page.html", "https://example.com/v1.2/page.html"},
+		{"decode unreserved", normalizeEncoding, "https://example.com/%7Euser/%41b", "https://example.com/~user/Ab"},
+		{"uppercase escapes", normalizeEncoding, "https://example.com/a%2fb%c3%a9", "https://example.com/a%2Fb%C3%A9"},
+		{"keep reserved escapes", normalizeEncoding, "https://example.com/a%20b", "https://example.com/a%20b"},
+		{"drop query", dropQuery, "https://example.com/a?page=2", "https://example.com/a"},
+		{"strip utm params", func(u *url.URL) { stripTrackingParams(u, DefaultTrackingParams) }, "https://example.com/a?utm_source=x&page=2&utm_medium=y", "https://example.com/a?page=2"},
+		{"strip fbclid", func(u *url.URL) { stripTrackingParams(u, DefaultTrackingParams) }, "https://example.com/a?fbclid=abc", "https://example.com/a"},
+		{"strip custom params", func(u *url.URL) { stripTrackingParams(u, []string{"sessionid"}) }, "https://example.com/a?sessionid=1&utm_source=x", "https://example.com/a?utm_source=x"},
+		{"sort query", sortQuery, "https://example.com/a?b=2&a=1&c=3", "https://example.com/a?a=1&b=2&c=3"},
+		{"sort keeps repeated order", sortQuery, "https://example.com/a?t=2&a=1&t=1", "https://example.com/a?a=1&t=2&t=1"},
+		{"remove fragment", removeFragment, "https://example.com/a#section", "https://example.com/a"},
+		{"strip trailing slash", func(u *url.URL) { applySlashPolicy(u, SlashStrip) }, "https://example.com/a//", "https://example.com/a"},
+		{"keep trailing slash", func(u *url.URL) { applySlashPolicy(u, SlashKeep) }, "https://example.com/a/", "https://example.com/a/"},
+		{"add trailing slash", func(u *url.URL) { applySlashPolicy(u, SlashAdd) }, "https://example.com/a", "https://example.com/a/"},
+		{"add skips files", func(u *url.URL) { applySlashPolicy(u, SlashAdd) }, "https://example.com/a.html", "https://example.com/a.html"},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			parsedURL, err := url.Parse(tc.inputURL)
+			if err != nil {
+				t.Fatalf("Test %v - '%s' FAIL: couldn't parse input: %v", i, tc.name, err)
+			}
+			tc.step(parsedURL)
+			if actual := parsedURL.String(); actual != tc.expected {
+				t.Errorf("Test %v - %s FAIL: expected URL: %v, actual: %v", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestURLNormalizerOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     NormalizeOptions
+		inputURL string
+		expected string
+	}{
+		{
+			name:     "default keeps meaningful query",
+			inputURL: "https://Example.com/Blog/?page=2&utm_source=news#comments",
+			expected: "example.com/Blog?page=2",
+		},
+		{
+			name:     "default treats query order as irrelevant",
+			inputURL: "https://example.com/search?q=go&lang=en",
+			expected: "example.com/search?lang=en&q=go",
+		},
+		{
+			name:     "keep scheme",
+			opts:     NormalizeOptions{Scheme: SchemeKeep},
+			inputURL: "http://example.com:80/a",
+			expected: "http://example.com/a",
+		},
+		{
+			name:     "upgrade scheme",
+			opts:     NormalizeOptions{Scheme: SchemeHTTPS},
+			inputURL: "http://example.com/a",
+			expected: "https://example.com/a",
+		},
+		{
+			name:     "keep default port",
+			opts:     NormalizeOptions{KeepDefaultPort: true},
+			inputURL: "https://example.com:443/a",
+			expected: "example.com:443/a",
+		},
+		{
+			name:     "drop query like older versions",
+			opts:     NormalizeOptions{DropQuery: true},
+			inputURL: "https://example.com/a?page=2",
+			expected: "example.com/a",
+		},
+		{
+			name:     "keep tracking params",
+			opts:     NormalizeOptions{TrackingParams: []string{}},
+			inputURL: "https://example.com/a?utm_source=x",
+			expected: "example.com/a?utm_source=x",
+		},
+		{
+			name:     "keep query order",
+			opts:     NormalizeOptions{KeepQueryOrder: true},
+			inputURL: "https://example.com/a?b=1&a=2",
+			expected: "example.com/a?b=1&a=2",
+		},
+		{
+			name:     "keep fragment",
+			opts:     NormalizeOptions{KeepFragment: true},
+			inputURL: "https://example.com/a#top",
+			expected: "example.com/a#top",
+		},
+		{
+			name:     "keep dot segments",
+			opts:     NormalizeOptions{KeepDotSegments: true},
+			inputURL: "https://example.com/a/../b",
+			expected: "example.com/a/../b",
+		},
+		{
+			name:     "keep encoding",
+			opts:     NormalizeOptions{KeepEncoding: true},
+			inputURL: "https://example.com/%7euser",
+			expected: "example.com/%7euser",
+		},
+		{
+			name:     "add trailing slash",
+			opts:     NormalizeOptions{TrailingSlash: SlashAdd},
+			inputURL: "https://example.com/docs",
+			expected: "example.com/docs/",
+		},
+		{
+			name:     "unicode host",
+			opts:     NormalizeOptions{IDN: IDNUnicode},
+			inputURL: "https://xn--bcher-kva.de/",
+			expected: "bücher.de",
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := newURLNormalizer(tc.opts).normalize(tc.inputURL)
+			if err != nil {
+				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+				return
+			}
+			if actual != tc.expected {
+				t.Errorf("Test %v - %s FAIL: expected URL: %v, actual: %v", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
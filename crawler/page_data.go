@@ -0,0 +1,138 @@
+package crawler
+
+import "net/url"
+
+// PageData represents structured data extracted from a web page.
+// The csv tag names the report column for each field (see report_columns.go);
+// tag new fields so they become available in reports automatically.
+type PageData struct {
+	URL              string            `csv:"page_url"`
+	H1               string            `csv:"h1"`
+	FirstParagraph   string            `csv:"first_paragraph"`
+	OutgoingLinks    []string          `csv:"outgoing_link_urls"`
+	Links            []Link            `csv:"-"` // Rich link records behind OutgoingLinks, see the edges report
+	ImageURLs        []string          `csv:"image_urls"`
+	Images           []ImageCandidate  `csv:"image_sources"`      // Every image candidate with the attribute it came from
+	Assets           []Asset           `csv:"assets"`             // Scripts, stylesheets, fonts, iframes, media, favicons, manifest and resource hints
+	ImagesMissingAlt int               `csv:"images_missing_alt"` // <img> tags without an alt attribute
+	MailtoLinks      int               `csv:"mailto_links"`       // mailto: links, which are never crawled
+	TelLinks         int               `csv:"tel_links"`          // tel: links, which are never crawled
+	DownloadLinks    int               `csv:"download_links"`     // Documents, archives and <a download> links, which are never crawled
+	Depth            int               `csv:"depth"`              // Links followed from the base URL, set by the crawler
+	StatusCode       int               `csv:"status_code"`        // HTTP status of the response, set by the crawler
+	SecurityHeaders  map[string]string `csv:"security_headers"`   // Security response headers present, set by the crawler
+	RedirectedTo     string            `csv:"redirected_to"`      // Final URL when the request was redirected, set by the crawler
+	InboundLinks     int               `csv:"inbound_links"`      // Distinct crawled pages linking here, set after the crawl
+	PageRank         float64           `csv:"page_rank"`          // Internal PageRank, set after the crawl
+	HubScore         float64           `csv:"hub_score"`          // HITS hub score, set after the crawl
+	AuthorityScore   float64           `csv:"authority_score"`    // HITS authority score, set after the crawl
+
+	// SEO metadata from the <head> and heading outline
+	Title             string            `csv:"title"`
+	MetaDescription   string            `csv:"meta_description"`
+	MetaRobots        string            `csv:"meta_robots"`
+	Robots            RobotsDirectives  `csv:"robots_directives"`  // Meta robots, crawler-specific meta and X-Robots-Tag combined
+	Canonical         string            `csv:"canonical_url"`      // Absolute URL from <link rel="canonical">
+	CanonicalVariants []string          `csv:"canonical_variants"` // URLs folded into this page by collapseByCanonical
+	Hreflang          []HreflangLink    `csv:"hreflang"`
+	OpenGraph         map[string]string `csv:"open_graph"`   // og:* properties, keyed by property name
+	TwitterCard       map[string]string `csv:"twitter_card"` // twitter:* tags, keyed by tag name
+	Viewport          string            `csv:"viewport"`
+	Lang              string            `csv:"lang"` // <html lang> attribute
+	Headings          []Heading         `csv:"headings"`
+
+	// Content fingerprints of the visible text, for duplicate detection
+	WordCount   int     `csv:"word_count"`
+	ContentHash string  `csv:"content_hash"` // SHA-256 of the normalized words
+	SimHash     simHash `csv:"simhash"`      // Near-duplicate fingerprint
+
+	// Custom fields set by extractors; each field is a report column of its own
+	Fields PageFields `csv:"-" json:",omitempty"`
+}
+
+// extractPageData extracts and structures all relevant data from an HTML page
+func extractPageData(html, pageURL string) PageData {
+	pageData, _ := extractPage(Response{ContentType: "text/html", Body: html}, pageURL, nil)
+	return pageData
+}
+
+// extractPage extracts the built-in data from a fetched page, then runs
+// the extractors. Extractor errors are returned along with everything the
+// page yielded.
+func extractPage(res Response, pageURL string, extractors []Extractor) (PageData, error) {
+	// Relative URLs resolve against where redirects ended, not the requested URL
+	base := pageURL
+	if res.FinalURL != "" {
+		base = res.FinalURL
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		// If URL parsing fails, return minimal data
+		return PageData{
+			URL:           pageURL,
+			OutgoingLinks: []string{},
+			ImageURLs:     []string{},
+		}, nil
+	}
+
+	// Parse the HTML once and run every extractor over the document
+	doc, err := parseHTML(res.Body)
+	if err != nil {
+		return PageData{
+			URL:           pageURL,
+			OutgoingLinks: []string{},
+			ImageURLs:     []string{},
+		}, nil
+	}
+
+	h1 := h1FromDocument(doc)
+	firstParagraph := firstParagraphFromDocument(doc)
+
+	links := linksFromDocument(doc, baseURL)
+	counts := countLinkKinds(links)
+	outgoingLinks := linkURLs(links)
+	if outgoingLinks == nil {
+		outgoingLinks = []string{}
+	}
+
+	images := imageCandidatesFromDocument(doc, baseURL)
+	imageURLs := imageCandidateURLs(images)
+	if imageURLs == nil {
+		imageURLs = []string{}
+	}
+
+	meta := seoMetadataFromDocument(doc, baseURL)
+	words := contentWords(visibleTextFromDocument(doc))
+	fields, err := runExtractors(extractors, doc, res)
+
+	// Return structured data
+	return PageData{
+		URL:              pageURL,
+		H1:               h1,
+		FirstParagraph:   firstParagraph,
+		OutgoingLinks:    outgoingLinks,
+		Links:            links,
+		ImageURLs:        imageURLs,
+		Images:           images,
+		Assets:           assetsFromDocument(doc, baseURL),
+		ImagesMissingAlt: imagesMissingAltFromDocument(doc),
+		MailtoLinks:      counts.Mailto,
+		TelLinks:         counts.Tel,
+		DownloadLinks:    counts.Download,
+		Title:            meta.Title,
+		MetaDescription:  meta.MetaDescription,
+		MetaRobots:       meta.MetaRobots,
+		Canonical:        meta.Canonical,
+		Hreflang:         meta.Hreflang,
+		OpenGraph:        meta.OpenGraph,
+		TwitterCard:      meta.TwitterCard,
+		Viewport:         meta.Viewport,
+		Lang:             meta.Lang,
+		Headings:         meta.Headings,
+		Robots:           robotsFromDocument(doc),
+		WordCount:        len(words),
+		ContentHash:      contentHash(words),
+		SimHash:          computeSimHash(words),
+		Fields:           fields,
+	}, err
+}
@@ -0,0 +1,167 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// fetchArchive is the file a Recorder saves and a ReplayFetcher serves from
+type fetchArchive struct {
+	Responses map[string]Response `json:"responses"`       // Keyed by requested URL
+	Heads     map[string]Response `json:"heads,omitempty"` // HEAD responses, such as image probes, keyed by URL
+}
+
+// Recorder wraps a Fetcher and remembers every response it returns, so the
+// crawl can be saved and replayed later without the network
+type Recorder struct {
+	next Fetcher
+
+	mu        sync.Mutex
+	responses map[string]Response
+	heads     map[string]Response
+}
+
+// NewRecorder records the responses next returns
+func NewRecorder(next Fetcher) *Recorder {
+	return &Recorder{next: next, responses: make(map[string]Response), heads: make(map[string]Response)}
+}
+
+// Fetch passes the request on and records the response. Failed requests
+// aren't recorded.
+func (r *Recorder) Fetch(ctx context.Context, rawURL string) (Response, error) {
+	res, err := r.next.Fetch(ctx, rawURL)
+	if err != nil {
+		return res, err
+	}
+
+	r.mu.Lock()
+	r.responses[rawURL] = res
+	r.mu.Unlock()
+	return res, nil
+}
+
+// Head passes a HEAD request on to the wrapped fetcher and records the
+// response. It fails when the wrapped fetcher isn't a HeadFetcher.
+func (r *Recorder) Head(ctx context.Context, rawURL string) (Response, error) {
+	next, ok := r.next.(HeadFetcher)
+	if !ok {
+		return Response{}, fmt.Errorf("the recorded fetcher can't send HEAD requests")
+	}
+	res, err := next.Head(ctx, rawURL)
+	if err != nil {
+		return res, err
+	}
+
+	r.mu.Lock()
+	r.heads[rawURL] = res
+	r.mu.Unlock()
+	return res, nil
+}
+
+// logIn runs the wrapped fetcher's form login, if it has one. The login
+// requests themselves aren't recorded.
+func (r *Recorder) logIn(log io.Writer) error {
+	if next, ok := r.next.(loginFetcher); ok {
+		return next.logIn(log)
+	}
+	return nil
+}
+
+// archiveSecretHeaders are response headers that carry sessions or
+// credentials. They are left out of saved archives.
+var archiveSecretHeaders = append([]string{"Set-Cookie", "Set-Cookie2", "Authentication-Info", "Proxy-Authentication-Info"}, secretHeaders...)
+
+// Save writes the recorded responses as indented JSON, without cookies or
+// credentials. The file is only readable by its owner, since pages behind
+// a login may still be private.
+func (r *Recorder) Save(filename string) error {
+	r.mu.Lock()
+	archive := fetchArchive{Responses: withoutSecrets(r.responses), Heads: withoutSecrets(r.heads)}
+	r.mu.Unlock()
+	data, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return fmt.Errorf("couldn't encode archive: %w", err)
+	}
+
+	// Tighten the mode of an existing archive before writing to it
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("couldn't write archive: %w", err)
+	}
+	if err := file.Chmod(0o600); err != nil {
+		file.Close()
+		return fmt.Errorf("couldn't write archive: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("couldn't write archive: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("couldn't write archive: %w", err)
+	}
+	return nil
+}
+
+// withoutSecrets copies the responses, dropping archiveSecretHeaders
+func withoutSecrets(responses map[string]Response) map[string]Response {
+	stripped := make(map[string]Response, len(responses))
+	for rawURL, res := range responses {
+		if res.Header != nil {
+			res.Header = res.Header.Clone()
+			for _, name := range archiveSecretHeaders {
+				res.Header.Del(name)
+			}
+		}
+		stripped[rawURL] = res
+	}
+	return stripped
+}
+
+// ReplayFetcher serves the responses saved by a Recorder. URLs that weren't
+// recorded fail, so a replayed crawl never reaches the network.
+type ReplayFetcher struct {
+	responses map[string]Response
+	heads     map[string]Response
+}
+
+// NewReplayFetcher reads an archive written by Recorder.Save
+func NewReplayFetcher(filename string) (*ReplayFetcher, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read archive: %w", err)
+	}
+
+	var archive fetchArchive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, fmt.Errorf("couldn't parse archive %s: %w", filename, err)
+	}
+	return &ReplayFetcher{responses: archive.Responses, heads: archive.Heads}, nil
+}
+
+// Fetch returns the recorded response for rawURL
+func (r *ReplayFetcher) Fetch(ctx context.Context, rawURL string) (Response, error) {
+	if err := ctx.Err(); err != nil {
+		return Response{}, err
+	}
+	res, ok := r.responses[rawURL]
+	if !ok {
+		return Response{}, fmt.Errorf("%s isn't in the archive", rawURL)
+	}
+	return res, nil
+}
+
+// Head returns the recorded HEAD response for rawURL
+func (r *ReplayFetcher) Head(ctx context.Context, rawURL string) (Response, error) {
+	if err := ctx.Err(); err != nil {
+		return Response{}, err
+	}
+	res, ok := r.heads[rawURL]
+	if !ok {
+		return Response{}, fmt.Errorf("%s isn't in the archive", rawURL)
+	}
+	return res, nil
+}
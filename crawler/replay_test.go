@@ -0,0 +1,152 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	site := MemoryFetcher{
+		"https://example.com":   {Body: `<title>Home</title><a href="/a">A</a>`},
+		"https://example.com/a": {Body: `<title>A</title><a href="/">Home</a>`},
+	}
+	crawl := func(fetcher Fetcher) Snapshot {
+		c, err := New(Options{URL: "https://example.com", Fetcher: fetcher})
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshot, err := c.Run(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return snapshot
+	}
+
+	// Record a crawl and save it
+	recorder := NewRecorder(site)
+	recorded := crawl(recorder)
+	archivePath := filepath.Join(t.TempDir(), "archive.json")
+	if err := recorder.Save(archivePath); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replaying the archive finds the same pages
+	replay, err := NewReplayFetcher(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := crawl(replay)
+	if !reflect.DeepEqual(replayed.Pages, recorded.Pages) {
+		t.Errorf("expected the replayed crawl to match:\n%+v\nvs\n%+v", recorded.Pages, replayed.Pages)
+	}
+
+	// The missing sitemap was recorded too
+	if _, err := replay.Fetch(context.Background(), "https://example.com/sitemap.xml"); err != nil {
+		t.Errorf("expected the sitemap response to be recorded: %v", err)
+	}
+
+	// Anything else was never recorded
+	_, err = replay.Fetch(context.Background(), "https://example.com/b")
+	if err == nil || !strings.Contains(err.Error(), "isn't in the archive") {
+		t.Errorf("expected an unrecorded URL to fail, got %v", err)
+	}
+}
+
+func TestRecordAndReplayHeads(t *testing.T) {
+	site := MemoryFetcher{
+		"https://example.com/logo.png": {ContentType: "image/png", Body: "PNG"},
+	}
+	ctx := context.Background()
+
+	recorder := NewRecorder(site)
+	recorded, err := recorder.Head(ctx, "https://example.com/logo.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorded.Body != "" || recorded.Header.Get("Content-Length") != "3" {
+		t.Errorf("expected headers only with the body length, got %+v", recorded)
+	}
+	archivePath := filepath.Join(t.TempDir(), "archive.json")
+	if err := recorder.Save(archivePath); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := NewReplayFetcher(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := replay.Head(ctx, "https://example.com/logo.png")
+	if err != nil || !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("expected the recorded HEAD response, got %+v (%v)", replayed, err)
+	}
+	if _, err := replay.Fetch(ctx, "https://example.com/logo.png"); err == nil {
+		t.Errorf("expected HEAD responses not to answer GET requests")
+	}
+
+	// A recorder around a fetcher without HEAD support can't probe
+	if _, ok := headFetcher(NewRecorder(getOnlyFetcher{site})); ok {
+		t.Errorf("expected a recorder around a GET-only fetcher not to probe")
+	}
+}
+
+// getOnlyFetcher hides every method but Fetch
+type getOnlyFetcher struct {
+	next Fetcher
+}
+
+func (f getOnlyFetcher) Fetch(ctx context.Context, rawURL string) (Response, error) {
+	return f.next.Fetch(ctx, rawURL)
+}
+
+func TestRecorderSaveLeavesOutSecrets(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "text/html")
+	header.Add("Set-Cookie", "session=abc")
+	header.Set("Authentication-Info", `nextnonce="xyz"`)
+	site := MemoryFetcher{"https://example.com": {Header: header, Body: "<title>Home</title>"}}
+
+	recorder := NewRecorder(site)
+	res, err := recorder.Fetch(context.Background(), "https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Start from a world-readable file to check the mode is tightened
+	archivePath := filepath.Join(t.TempDir(), "archive.json")
+	if err := os.WriteFile(archivePath, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Save(archivePath); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"session=abc", "xyz"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("expected %q to be left out of the archive:\n%s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), "text/html") {
+		t.Errorf("expected the other headers to be kept:\n%s", data)
+	}
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	// The crawl itself still saw the cookie
+	if res.Header.Get("Set-Cookie") != "session=abc" {
+		t.Errorf("expected the live response to keep its headers, got %v", res.Header)
+	}
+}
@@ -0,0 +1,161 @@
+package crawler
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// reportColumn is one selectable report column, backed by a PageData field
+// or by a custom field in PageData.Fields
+type reportColumn struct {
+	Name   string
+	Multi  bool   // Field holds several values (a slice or map)
+	index  []int  // Field index for reflect.Value.FieldByIndex
+	custom string // Custom field name; index is unused when set
+}
+
+// values returns the column's value(s) for a page as strings
+func (col reportColumn) values(pageData PageData) []string {
+	if col.custom != "" {
+		return pageData.Fields[col.custom]
+	}
+	field := reflect.ValueOf(pageData).FieldByIndex(col.index)
+	return formatReportValue(field)
+}
+
+// pageDataColumns is the registry of every tagged PageData field, in declaration order
+var pageDataColumns = buildColumnRegistry(reflect.TypeOf(PageData{}))
+
+// DefaultCSVColumns are the columns written when none are requested,
+// followed by the custom fields
+var DefaultCSVColumns = []string{
+	"page_url", "h1", "first_paragraph", "outgoing_link_urls", "image_urls",
+	"title", "meta_description", "meta_robots", "canonical_url",
+	"inbound_links", "page_rank", "hub_score", "authority_score",
+	"mailto_links", "tel_links", "download_links",
+}
+
+// buildColumnRegistry collects a column for each field with a csv tag.
+// Fields tagged `csv:"-"` are left out of reports.
+func buildColumnRegistry(pageType reflect.Type) []reportColumn {
+	var columns []reportColumn
+
+	for i := 0; i < pageType.NumField(); i++ {
+		field := pageType.Field(i)
+		name := field.Tag.Get("csv")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		kind := field.Type.Kind()
+		columns = append(columns, reportColumn{
+			Name:  name,
+			Multi: kind == reflect.Slice || kind == reflect.Map,
+			index: field.Index,
+		})
+	}
+
+	return columns
+}
+
+// availableColumns returns the registry followed by a column for each
+// custom field
+func availableColumns(fields []string) []reportColumn {
+	columns := append([]reportColumn(nil), pageDataColumns...)
+	for _, name := range fields {
+		columns = append(columns, reportColumn{Name: name, Multi: true, custom: name})
+	}
+	return columns
+}
+
+// columnNames lists the name of every column
+func columnNames(columns []reportColumn) []string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// ValidateColumns reports an error for any name that is neither a
+// report.csv column nor one of the custom fields
+func ValidateColumns(names, fields []string) error {
+	_, err := lookupColumns(names, fields)
+	return err
+}
+
+// lookupColumns resolves column names against the registry and the custom
+// fields, keeping the requested order
+func lookupColumns(names, fields []string) ([]reportColumn, error) {
+	available := availableColumns(fields)
+	byName := make(map[string]reportColumn, len(available))
+	for _, col := range available {
+		byName[col.Name] = col
+	}
+
+	columns := make([]reportColumn, 0, len(names))
+	for _, name := range names {
+		col, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q (available: %s)", name, strings.Join(columnNames(available), ", "))
+		}
+		columns = append(columns, col)
+	}
+
+	return columns, nil
+}
+
+// formatReportValue turns a field into one string per value.
+// Scalars produce a single value, slices one per element, and maps one
+// "key=value" entry per key in sorted order.
+func formatReportValue(value reflect.Value) []string {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			// Byte slices are a single value, not a list of numbers
+			return []string{formatScalar(value)}
+		}
+		values := make([]string, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			values = append(values, formatScalar(value.Index(i)))
+		}
+		return values
+	case reflect.Map:
+		values := make([]string, 0, value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			values = append(values, formatScalar(iter.Key())+"="+formatScalar(iter.Value()))
+		}
+		sort.Strings(values)
+		return values
+	default:
+		return []string{formatScalar(value)}
+	}
+}
+
+// formatScalar formats a single value, preferring a String method when the type has one
+func formatScalar(value reflect.Value) string {
+	if value.CanInterface() {
+		if stringer, ok := value.Interface().(fmt.Stringer); ok {
+			return stringer.String()
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64)
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool())
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
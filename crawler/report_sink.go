@@ -0,0 +1,70 @@
+package crawler
+
+// PageWriter receives crawled pages one at a time, as soon as they finish
+type PageWriter interface {
+	WritePage(pageData PageData) error
+	Close() error
+}
+
+// pageFunc adapts a callback to a PageWriter that needs no closing
+type pageFunc func(pageData PageData) error
+
+func (fn pageFunc) WritePage(pageData PageData) error { return fn(pageData) }
+
+func (fn pageFunc) Close() error { return nil }
+
+// reportSink streams completed pages to one or more pageWriters.
+// Pages are queued on a buffered channel and written by a single goroutine,
+// so writers never need their own locking.
+type reportSink struct {
+	pages   chan PageData
+	writers []PageWriter
+	done    chan struct{}
+	err     error
+}
+
+// newReportSink starts the writer goroutine and returns a sink ready to accept pages
+func newReportSink(bufferSize int, writers ...PageWriter) *reportSink {
+	sink := &reportSink{
+		pages:   make(chan PageData, bufferSize),
+		writers: writers,
+		done:    make(chan struct{}),
+	}
+
+	go sink.run()
+
+	return sink
+}
+
+func (sink *reportSink) run() {
+	defer close(sink.done)
+
+	for pageData := range sink.pages {
+		for _, writer := range sink.writers {
+			if err := writer.WritePage(pageData); err != nil && sink.err == nil {
+				// Keep draining the channel so the crawl never blocks on a broken writer
+				sink.err = err
+			}
+		}
+	}
+}
+
+// send queues a page for writing. It blocks only when the buffer is full.
+func (sink *reportSink) send(pageData PageData) {
+	sink.pages <- pageData
+}
+
+// close waits for every queued page to be written, closes the writers
+// and returns the first error encountered
+func (sink *reportSink) close() error {
+	close(sink.pages)
+	<-sink.done
+
+	for _, writer := range sink.writers {
+		if err := writer.Close(); err != nil && sink.err == nil {
+			sink.err = err
+		}
+	}
+
+	return sink.err
+}
@@ -0,0 +1,66 @@
+package crawler
+
+import (
+	"errors"
+	"testing"
+)
+
+// memoryPageWriter collects pages in memory for testing
+type memoryPageWriter struct {
+	pages  []PageData
+	closed bool
+	err    error
+}
+
+func (w *memoryPageWriter) WritePage(pageData PageData) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.pages = append(w.pages, pageData)
+	return nil
+}
+
+func (w *memoryPageWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestReportSinkDeliversEveryPage(t *testing.T) {
+	first := &memoryPageWriter{}
+	second := &memoryPageWriter{}
+	sink := newReportSink(2, first, second)
+
+	urls := []string{"https://example.com", "https://example.com/a", "https://example.com/b"}
+	for _, u := range urls {
+		sink.send(PageData{URL: u})
+	}
+
+	if err := sink.close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, writer := range []*memoryPageWriter{first, second} {
+		if len(writer.pages) != len(urls) {
+			t.Errorf("expected %d pages, got %d", len(urls), len(writer.pages))
+		}
+		if !writer.closed {
+			t.Errorf("expected writer to be closed")
+		}
+	}
+}
+
+func TestReportSinkReturnsWriterError(t *testing.T) {
+	expected := errors.New("disk full")
+	writer := &memoryPageWriter{err: expected}
+	sink := newReportSink(0, writer)
+
+	sink.send(PageData{URL: "https://example.com"})
+	sink.send(PageData{URL: "https://example.com/a"})
+
+	if err := sink.close(); !errors.Is(err, expected) {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+	if !writer.closed {
+		t.Errorf("expected writer to be closed")
+	}
+}
@@ -0,0 +1,89 @@
+package crawler
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SortKey selects the row order of a report
+type SortKey string
+
+const (
+	SortByURL     SortKey = "url"     // Page URL, alphabetically
+	SortByDepth   SortKey = "depth"   // Shallowest pages first
+	SortByInbound SortKey = "inbound" // Most linked-to pages first
+	SortByStatus  SortKey = "status"  // Lowest HTTP status first
+)
+
+// DefaultSortKey keeps reports stable between runs so they diff cleanly
+const DefaultSortKey = SortByURL
+
+// ParseSortKey converts a user-supplied sort name into a SortKey
+func ParseSortKey(raw string) (SortKey, error) {
+	switch key := SortKey(raw); key {
+	case "":
+		return DefaultSortKey, nil
+	case SortByURL, SortByDepth, SortByInbound, SortByStatus:
+		return key, nil
+	default:
+		return "", fmt.Errorf("unknown sort key %q (expected url, depth, inbound or status)", raw)
+	}
+}
+
+// sortPages returns the pages as a slice in the requested order.
+// Ties are always broken by URL so the order is fully deterministic.
+// Sorting by inbound links uses InboundLinks, set by applyInboundCounts.
+func sortPages(pages map[string]PageData, key SortKey) []PageData {
+	// Compute every sort key once, not on each comparison
+	type keyedPage struct {
+		entry    sortEntry
+		pageData PageData
+	}
+	keyed := make([]keyedPage, 0, len(pages))
+	for _, pageData := range pages {
+		keyed = append(keyed, keyedPage{entry: sortEntryFor(pageData), pageData: pageData})
+	}
+
+	sort.Slice(keyed, func(i, j int) bool {
+		return sortEntryLess(key, keyed[i].entry, keyed[j].entry)
+	})
+
+	sorted := make([]PageData, len(keyed))
+	for i, page := range keyed {
+		sorted[i] = page.pageData
+	}
+	return sorted
+}
+
+// sortEntry holds the fields a page is sorted on
+type sortEntry struct {
+	URL        string
+	Depth      int
+	StatusCode int
+	Inbound    int
+}
+
+// sortEntryFor returns a page's sort fields
+func sortEntryFor(pageData PageData) sortEntry {
+	return sortEntry{URL: pageData.URL, Depth: pageData.Depth, StatusCode: pageData.StatusCode, Inbound: pageData.InboundLinks}
+}
+
+// sortEntryLess orders two pages by key, breaking ties by URL
+func sortEntryLess(key SortKey, a, b sortEntry) bool {
+	switch key {
+	case SortByDepth:
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+	case SortByInbound:
+		if a.Inbound != b.Inbound {
+			return a.Inbound > b.Inbound
+		}
+	case SortByStatus:
+		if a.StatusCode != b.StatusCode {
+			return a.StatusCode < b.StatusCode
+		}
+	}
+
+	return a.URL < b.URL
+}
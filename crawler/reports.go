@@ -0,0 +1,161 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+)
+
+// ReportOptions controls the reports written after a crawl
+type ReportOptions struct {
+	OutputDir          string          // Directory for every report; empty means the current directory
+	CSV                CSVOptions      // Layout of the page report, see CSVOptions
+	Robots             RobotsPolicy    // Leave noindex pages out of report.csv when respecting robots directives
+	CollapseCanonicals bool            // Fold pages into their declared canonical in report.csv
+	ProbeImages        bool            // Send a HEAD request to each unique image
+	Fetch              FetchOptions    // Headers, credentials, cookies and rate limit of the probes; zero Timeout means 15 seconds
+	MaxImageBytes      int64           // Size above which a probed image is oversized; zero means DefaultMaxImageBytes
+	CollapseQuery      bool            // Merge URLs that differ only in their query string into one link graph node
+	KeepSelfLoops      bool            // Keep links from a page to itself in the link graph
+	PageRank           PageRankOptions // Damping, convergence and nofollow handling of the link scores; zero fields mean the defaults
+	Rules              RulesOptions    // Thresholds, severities and disabled rules of the issues report
+	DuplicateThreshold float64         // Minimum SimHash similarity for near duplicates; zero means 0.9
+}
+
+// Validate checks the analysis settings before any report is written.
+// Zero PageRank fields are checked as their defaults.
+func (opts ReportOptions) Validate() error {
+	if err := opts.PageRank.WithDefaults().Validate(); err != nil {
+		return err
+	}
+	if err := opts.Rules.Validate(); err != nil {
+		return err
+	}
+	if opts.MaxImageBytes < 0 {
+		return fmt.Errorf("image size limit must not be negative")
+	}
+	if opts.DuplicateThreshold < 0 || opts.DuplicateThreshold > 1 {
+		return fmt.Errorf("duplicate threshold must be between 0 and 1, got %v", opts.DuplicateThreshold)
+	}
+	return nil
+}
+
+// WriteReports scores the crawled pages and writes every report into the
+// output directory, printing one line per report to out. Scores are stored
+// on the snapshot's pages, so save the snapshot afterwards to keep them.
+// Images are probed with an HTTP fetcher built from opts.Fetch.
+func WriteReports(snapshot Snapshot, opts ReportOptions, out io.Writer) error {
+	if !opts.ProbeImages {
+		return writeReports(snapshot, opts, out, nil)
+	}
+
+	fetchOpts := opts.Fetch
+	if fetchOpts.Timeout == 0 {
+		fetchOpts.Timeout = defaultProbeTimeout
+	}
+	fetcher, err := newHTTPFetcher(fetchOpts)
+	if err != nil {
+		return fmt.Errorf("couldn't create image fetcher: %w", err)
+	}
+	return writeReports(snapshot, opts, out, fetcher)
+}
+
+// writeReports writes every report, probing images with fetcher
+func writeReports(snapshot Snapshot, opts ReportOptions, out io.Writer, fetcher HeadFetcher) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	baseURL, err := url.Parse(snapshot.BaseURL)
+	if err != nil {
+		return fmt.Errorf("couldn't parse base URL: %w", err)
+	}
+	pages := snapshot.Pages
+	normalizer := newURLNormalizer(snapshot.Normalize) // Links resolve to the keys the crawl built
+	path := func(name string) string { return filepath.Join(opts.OutputDir, name) }
+
+	// Every other report shares the page report's delimiter and BOM, but not its columns
+	shared := CSVOptions{Delimiter: opts.CSV.Delimiter, MultiSeparator: opts.CSV.MultiSeparator, BOM: opts.CSV.BOM}
+
+	// Score pages by how the internal linking favors them
+	graphOpts := graphOptions{CollapseQuery: opts.CollapseQuery, DropSelfLoops: !opts.KeepSelfLoops, normalizer: normalizer}
+	graph := buildLinkGraph(pages, graphOpts)
+	applyLinkScores(pages, graph, graphOpts, opts.PageRank)
+	applyInboundCounts(pages, graph, graphOpts)
+
+	// Write the page report in a stable order, with the crawl's custom fields
+	if opts.CSV.Fields == nil {
+		opts.CSV.Fields = snapshot.Fields
+	}
+	cfg := &config{pages: pages, robotsPolicy: opts.Robots, collapseCanonicals: opts.CollapseCanonicals, normalizer: normalizer}
+	if err := writeCSVReport(cfg.reportPages(), path("report.csv"), opts.CSV); err != nil {
+		return fmt.Errorf("couldn't write CSV report: %w", err)
+	}
+	fmt.Fprintf(out, "Report successfully written to %s\n", path("report.csv"))
+
+	// Write the link edge list
+	if err := writeEdgesCSV(pages, path("edges.csv"), shared); err != nil {
+		return fmt.Errorf("couldn't write edges report: %w", err)
+	}
+	fmt.Fprintf(out, "Link edges successfully written to %s\n", path("edges.csv"))
+
+	// Flag orphan, weakly linked, dead-end and deep pages
+	findings := analyzeLinkStructure(graph, snapshot.BaseURL, graphOpts, structureOptions{})
+	if err := writeStructureCSV(findings, path("structure.csv"), shared); err != nil {
+		return fmt.Errorf("couldn't write structure report: %w", err)
+	}
+	fmt.Fprintf(out, "%d structural findings written to %s\n", len(findings), path("structure.csv"))
+
+	// Run the SEO rules, checking noindex pages against the sitemap
+	issues := runSEORules(pages, snapshot.SitemapURLs, normalizer, opts.Rules)
+	if err := writeIssuesCSV(issues, path("issues.csv"), shared); err != nil {
+		return fmt.Errorf("couldn't write issues report: %w", err)
+	}
+	fmt.Fprintf(out, "%d SEO issues written to %s\n", len(issues), path("issues.csv"))
+
+	// Group pages by their declared canonical and flag conflicts
+	canonicals := analyzeCanonicals(pages, normalizer)
+	if err := writeCanonicalsCSV(canonicals, path("canonicals.csv"), shared); err != nil {
+		return fmt.Errorf("couldn't write canonicals report: %w", err)
+	}
+	fmt.Fprintf(out, "%d canonical declarations written to %s\n", len(canonicals), path("canonicals.csv"))
+
+	// Group pages with identical or nearly identical content
+	clusters := findDuplicates(pages, duplicateOptions{SimilarityThreshold: opts.DuplicateThreshold})
+	if err := writeDuplicatesCSV(clusters, path("duplicates.csv"), shared); err != nil {
+		return fmt.Errorf("couldn't write duplicates report: %w", err)
+	}
+	fmt.Fprintf(out, "%d duplicate clusters written to %s\n", len(clusters), path("duplicates.csv"))
+
+	// Flag mixed content, insecure internal links and missing security headers
+	securityFindings := analyzeSecurity(pages)
+	if err := writeSecurityCSV(securityFindings, path("security.csv"), shared); err != nil {
+		return fmt.Errorf("couldn't write security report: %w", err)
+	}
+	fmt.Fprintf(out, "%d security findings written to %s\n", len(securityFindings), path("security.csv"))
+
+	// Audit every unique image, probing each one once
+	images := auditImages(pages, imageAuditOptions{Probe: opts.ProbeImages, MaxBytes: opts.MaxImageBytes, fetcher: fetcher})
+	if err := writeImagesCSV(images, path("images.csv"), shared); err != nil {
+		return fmt.Errorf("couldn't write images report: %w", err)
+	}
+	fmt.Fprintf(out, "%d images written to %s\n", len(images), path("images.csv"))
+
+	// List scripts, stylesheets, fonts and other assets for performance and privacy reviews
+	assets := buildAssetInventory(pages, baseURL)
+	if err := writeAssetsCSV(assets, path("assets.csv"), shared); err != nil {
+		return fmt.Errorf("couldn't write assets report: %w", err)
+	}
+	fmt.Fprintf(out, "%d assets written to %s\n", len(assets), path("assets.csv"))
+
+	// Export the link graph for Graphviz, yEd and Gephi
+	for _, format := range []graphFormat{graphFormatDOT, graphFormatGraphML, graphFormatGEXF} {
+		filename := path("linkgraph." + string(format))
+		if err := writeGraphFile(graph, filename, format); err != nil {
+			return fmt.Errorf("couldn't write link graph: %w", err)
+		}
+		fmt.Fprintf(out, "Link graph successfully written to %s\n", filename)
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// RobotsPolicy decides whether the crawler obeys robots directives
+type RobotsPolicy string
+
+const (
+	RobotsRespect RobotsPolicy = "respect" // Don't follow nofollow links/pages, leave noindex pages out of reports
+	RobotsIgnore  RobotsPolicy = "ignore"  // Crawl and report everything, but still record the directives
+)
+
+// ParseRobotsPolicy converts a user-supplied policy name into a RobotsPolicy
+func ParseRobotsPolicy(raw string) (RobotsPolicy, error) {
+	switch policy := RobotsPolicy(raw); policy {
+	case "":
+		return RobotsRespect, nil
+	case RobotsRespect, RobotsIgnore:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown robots policy %q (expected respect or ignore)", raw)
+	}
+}
+
+// robotsAgents are the crawler names whose specific meta tags and
+// X-Robots-Tag values apply to us, besides the generic "robots"
+var robotsAgents = []string{"linkscout", "bootcrawler"}
+
+// RobotsDirectives are the indexing rules a page declares for crawlers
+type RobotsDirectives struct {
+	NoIndex   bool
+	NoFollow  bool
+	NoArchive bool
+	NoSnippet bool
+	Sources   []string // Every directive seen, e.g. "meta robots: noindex" or "X-Robots-Tag: googlebot: nofollow"
+}
+
+// String lists the directives that apply to us, e.g. "noindex,nofollow"
+func (d RobotsDirectives) String() string {
+	var active []string
+	if d.NoIndex {
+		active = append(active, "noindex")
+	}
+	if d.NoFollow {
+		active = append(active, "nofollow")
+	}
+	if d.NoArchive {
+		active = append(active, "noarchive")
+	}
+	if d.NoSnippet {
+		active = append(active, "nosnippet")
+	}
+	return strings.Join(active, ",")
+}
+
+// apply turns on every directive in a comma-separated list
+func (d *RobotsDirectives) apply(list string) {
+	for _, directive := range strings.Split(strings.ToLower(list), ",") {
+		switch strings.TrimSpace(directive) {
+		case "noindex":
+			d.NoIndex = true
+		case "nofollow":
+			d.NoFollow = true
+		case "none":
+			d.NoIndex = true
+			d.NoFollow = true
+		case "noarchive":
+			d.NoArchive = true
+		case "nosnippet":
+			d.NoSnippet = true
+		}
+	}
+}
+
+// isRobotsAgent reports whether a meta name or X-Robots-Tag agent targets us
+func isRobotsAgent(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "robots" {
+		return true
+	}
+	for _, agent := range robotsAgents {
+		if name == agent {
+			return true
+		}
+	}
+	return false
+}
+
+// robotsMetaNames are meta names treated as crawler directives even when
+// they target another crawler, so they are recorded
+var robotsMetaNames = map[string]bool{
+	"googlebot": true, "googlebot-news": true, "bingbot": true, "slurp": true,
+}
+
+// robotsFromDocument reads <meta name="robots"> and crawler-specific meta
+// tags. Directives for other crawlers are recorded in Sources but not applied.
+func robotsFromDocument(doc *goquery.Document) RobotsDirectives {
+	var directives RobotsDirectives
+
+	doc.Find("meta[name][content]").Each(func(_ int, s *goquery.Selection) {
+		name := strings.ToLower(strings.TrimSpace(s.AttrOr("name", "")))
+		content := strings.TrimSpace(s.AttrOr("content", ""))
+
+		switch {
+		case isRobotsAgent(name):
+			directives.apply(content)
+		case !robotsMetaNames[name]:
+			return
+		}
+		directives.Sources = append(directives.Sources, "meta "+name+": "+content)
+	})
+
+	return directives
+}
+
+// addRobotsHeader merges X-Robots-Tag header values into the directives.
+// A value may be scoped to one crawler with an "agent:" prefix.
+func (d *RobotsDirectives) addRobotsHeader(values []string) {
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		d.Sources = append(d.Sources, "X-Robots-Tag: "+value)
+
+		agent, list, scoped := strings.Cut(value, ":")
+		if scoped && !strings.Contains(agent, ",") && !isDirectiveName(agent) {
+			if isRobotsAgent(agent) {
+				d.apply(list)
+			}
+			continue
+		}
+		d.apply(value)
+	}
+}
+
+// isDirectiveName reports whether s is a directive rather than a crawler name,
+// so values such as "unavailable_after: 2026-01-01" aren't mistaken for agents
+func isDirectiveName(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "all", "noindex", "nofollow", "none", "noarchive", "nosnippet", "notranslate",
+		"noimageindex", "unavailable_after", "max-snippet", "max-image-preview", "max-video-preview", "indexifembedded":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package crawler
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRobotsFromDocument(t *testing.T) {
+	doc, err := parseHTML(`<html><head>
+		<meta name="ROBOTS" content="noindex">
+		<meta name="linkscout" content="nofollow, nosnippet">
+		<meta name="googlebot" content="noarchive">
+		<meta name="description" content="not a directive">
+	</head></html>`)
+	if err != nil {
+		t.Fatalf("couldn't parse HTML: %v", err)
+	}
+
+	actual := robotsFromDocument(doc)
+	expected := RobotsDirectives{
+		NoIndex:   true,
+		NoFollow:  true,
+		NoSnippet: true,
+		Sources: []string{
+			"meta robots: noindex",
+			"meta linkscout: nofollow, nosnippet",
+			"meta googlebot: noarchive",
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+	if actual.String() != "noindex,nofollow,nosnippet" {
+		t.Errorf("unexpected String(): %q", actual.String())
+	}
+}
+
+func TestAddRobotsHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		expected string
+	}{
+		{name: "generic", values: []string{"noindex, nofollow"}, expected: "noindex,nofollow"},
+		{name: "none", values: []string{"none"}, expected: "noindex,nofollow"},
+		{name: "other crawler", values: []string{"googlebot: noindex"}, expected: ""},
+		{name: "our crawler", values: []string{"LinkScout: noarchive"}, expected: "noarchive"},
+		{name: "directive with colon", values: []string{"unavailable_after: 25 Jun 2030 15:00:00 PST"}, expected: ""},
+		{name: "several headers", values: []string{"noarchive", "bingbot: noindex", "nosnippet"}, expected: "noarchive,nosnippet"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var directives RobotsDirectives
+			directives.addRobotsHeader(tc.values)
+			if actual := directives.String(); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+			if len(directives.Sources) != len(tc.values) {
+				t.Errorf("expected every header to be recorded, got %v", directives.Sources)
+			}
+		})
+	}
+}
+
+func TestLinksToFollow(t *testing.T) {
+	pageData := PageData{
+		OutgoingLinks: []string{"https://example.com/a", "https://example.com/b"},
+		Links: []Link{
+			{URL: "https://example.com/a"},
+			{URL: "https://example.com/b", Rel: []string{"nofollow"}},
+		},
+	}
+
+	respect := &config{robotsPolicy: RobotsRespect}
+	ignore := &config{robotsPolicy: RobotsIgnore}
+
+	if actual := respect.linksToFollow(pageData); !reflect.DeepEqual(actual, []string{"https://example.com/a"}) {
+		t.Errorf("respect: expected only the followed link, got %v", actual)
+	}
+	if actual := ignore.linksToFollow(pageData); len(actual) != 2 {
+		t.Errorf("ignore: expected every link, got %v", actual)
+	}
+
+	pageData.Robots.NoFollow = true
+	if actual := respect.linksToFollow(pageData); len(actual) != 0 {
+		t.Errorf("respect: expected no links from a nofollow page, got %v", actual)
+	}
+
+	pageData.Robots.NoIndex = true
+	if respect.shouldReport(pageData) || !ignore.shouldReport(pageData) {
+		t.Errorf("noindex pages must only be left out when respecting robots")
+	}
+
+	// Skipped links are counted so the crawl can say so
+	var log bytes.Buffer
+	respect.log = &log
+	respect.logRobotsSkips()
+	if !strings.Contains(log.String(), "kept 3 nofollow links from being followed") {
+		t.Errorf("expected a notice about the 3 skipped links, got %q", log.String())
+	}
+	ignore.log = &log
+	log.Reset()
+	ignore.logRobotsSkips()
+	if log.Len() != 0 {
+		t.Errorf("ignore: expected no notice, got %q", log.String())
+	}
+}
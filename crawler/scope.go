@@ -0,0 +1,58 @@
+package crawler
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// urlScope limits which pages the crawler enqueues. Patterns are regular
+// expressions matched against the absolute URL.
+type urlScope struct {
+	include []*regexp.Regexp // Empty means every URL on the site
+	exclude []*regexp.Regexp // Checked after include, and always wins
+}
+
+// newURLScope compiles the include and exclude patterns
+func newURLScope(include, exclude []string) (urlScope, error) {
+	var scope urlScope
+	var err error
+	if scope.include, err = compilePatterns(include); err != nil {
+		return urlScope{}, fmt.Errorf("couldn't compile include pattern: %w", err)
+	}
+	if scope.exclude, err = compilePatterns(exclude); err != nil {
+		return urlScope{}, fmt.Errorf("couldn't compile exclude pattern: %w", err)
+	}
+	return scope, nil
+}
+
+// compilePatterns compiles every pattern, stopping at the first invalid one
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// allows reports whether a URL matches an include pattern (or there are
+// none) and no exclude pattern
+func (s urlScope) allows(rawURL string) bool {
+	if len(s.include) > 0 && !matchesAny(s.include, rawURL) {
+		return false
+	}
+	return !matchesAny(s.exclude, rawURL)
+}
+
+// matchesAny reports whether any pattern matches s
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package crawler
+
+import "testing"
+
+func TestURLScope(t *testing.T) {
+	scope, err := newURLScope([]string{`^https://example\.com/docs/`}, []string{`\?print=`, `/docs/old/`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{"https://example.com/docs/intro", true},
+		{"https://example.com/blog/post", false},
+		{"https://example.com/docs/intro?print=1", false},
+		{"https://example.com/docs/old/intro", false},
+	}
+	for i, tc := range tests {
+		if actual := scope.allows(tc.url); actual != tc.expected {
+			t.Errorf("Test %v - %s FAIL: expected %v, got %v", i, tc.url, tc.expected, actual)
+		}
+	}
+
+	if _, err := newURLScope(nil, []string{"("}); err == nil {
+		t.Error("expected an invalid pattern to fail")
+	}
+}
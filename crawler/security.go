@@ -0,0 +1,158 @@
+package crawler
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Security finding kinds
+const (
+	securityMixedActive   = "mixed_content_active"  // HTTPS page loads an http:// script, stylesheet, font, iframe or hinted resource
+	securityMixedPassive  = "mixed_content_passive" // HTTPS page loads an http:// image or media file
+	securityInsecureLink  = "insecure_link"         // HTTPS page links to an http:// URL on the same site
+	securityMissingHeader = "missing_header"        // Response lacks a recommended security header
+)
+
+// securityHeaderNames are the response headers recorded for each page.
+// HSTS only matters on HTTPS responses.
+var securityHeaderNames = []string{
+	"Strict-Transport-Security",
+	"Content-Security-Policy",
+	"X-Content-Type-Options",
+	"X-Frame-Options",
+	"Referrer-Policy",
+	"Permissions-Policy",
+}
+
+// securityHeadersFrom picks the security headers out of a response,
+// keyed by canonical header name
+func securityHeadersFrom(header http.Header) map[string]string {
+	headers := map[string]string{}
+	for _, name := range securityHeaderNames {
+		if value := header.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+	return headers
+}
+
+// securityFinding is one security problem on one page
+type securityFinding struct {
+	URL      string
+	Severity issueSeverity
+	Kind     string
+	Target   string // Insecure resource or link URL, or the missing header's name
+	Detail   string
+}
+
+// passiveAssetTypes can't read or change the page, so browsers only warn about them
+var passiveAssetTypes = map[string]bool{assetImage: true, assetVideo: true, assetAudio: true}
+
+// analyzeSecurity flags mixed content and insecure internal links on HTTPS
+// pages, and missing security headers on every response. Findings are sorted
+// by severity (most severe first), then kind, page URL and target.
+func analyzeSecurity(pages map[string]PageData) []securityFinding {
+	var findings []securityFinding
+
+	for _, pageData := range sortPages(pages, SortByURL) {
+		pageURL, err := url.Parse(pageData.URL)
+		if err != nil {
+			continue
+		}
+		secure := strings.EqualFold(pageURL.Scheme, "https")
+
+		if secure {
+			seen := make(map[string]bool)
+			flag := func(kind, target, detail string, severity issueSeverity) {
+				if seen[kind+" "+target] {
+					return
+				}
+				seen[kind+" "+target] = true
+				findings = append(findings, securityFinding{
+					URL: pageData.URL, Severity: severity, Kind: kind, Target: target, Detail: detail,
+				})
+			}
+
+			for _, image := range pageData.Images {
+				if isInsecureURL(image.URL) {
+					flag(securityMixedPassive, image.URL, "image from "+image.Source, severityWarning)
+				}
+			}
+			for _, asset := range pageData.Assets {
+				if !isInsecureURL(asset.URL) {
+					continue
+				}
+				if passiveAssetTypes[asset.Type] {
+					flag(securityMixedPassive, asset.URL, asset.Type, severityWarning)
+				} else {
+					flag(securityMixedActive, asset.URL, asset.Type, severityError)
+				}
+			}
+			for _, link := range pageLinks(pageData) {
+				linkURL, err := url.Parse(link.URL)
+				if err != nil || !isInsecureURL(link.URL) {
+					continue
+				}
+				if strings.EqualFold(linkURL.Hostname(), pageURL.Hostname()) {
+					flag(securityInsecureLink, link.URL, "internal link over http", severityWarning)
+				}
+			}
+		}
+
+		// Only pages whose response was recorded have headers to check
+		if pageData.StatusCode == 0 {
+			continue
+		}
+		for _, name := range securityHeaderNames {
+			if name == "Strict-Transport-Security" && !secure {
+				continue
+			}
+			if _, ok := pageData.SecurityHeaders[name]; ok {
+				continue
+			}
+			severity := severityInfo
+			if name == "Strict-Transport-Security" {
+				severity = severityWarning
+			}
+			findings = append(findings, securityFinding{
+				URL: pageData.URL, Severity: severity, Kind: securityMissingHeader, Target: name,
+			})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity != b.Severity {
+			return a.Severity > b.Severity
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		return a.Target < b.Target
+	})
+
+	return findings
+}
+
+// isInsecureURL reports whether a URL is fetched over plain http
+func isInsecureURL(rawURL string) bool {
+	return len(rawURL) >= 7 && strings.EqualFold(rawURL[:7], "http://")
+}
+
+// securityHeader is the column layout of the security report
+var securityHeader = []string{"severity", "finding", "page_url", "target", "detail"}
+
+// writeSecurityCSV writes one row per security finding
+func writeSecurityCSV(findings []securityFinding, filename string, opts CSVOptions) error {
+	rows := make([][]string, len(findings))
+	for i, finding := range findings {
+		rows[i] = []string{finding.Severity.String(), finding.Kind, finding.URL, finding.Target, finding.Detail}
+	}
+
+	return writeCSVRows(filename, opts, securityHeader, rows)
+}
@@ -0,0 +1,113 @@
+package crawler
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSecurityHeadersFrom(t *testing.T) {
+	header := http.Header{}
+	header.Set("strict-transport-security", "max-age=31536000")
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Server", "nginx")
+
+	expected := map[string]string{
+		"Strict-Transport-Security": "max-age=31536000",
+		"X-Content-Type-Options":    "nosniff",
+	}
+	if actual := securityHeadersFrom(header); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestAnalyzeSecurity(t *testing.T) {
+	allHeaders := securityHeadersFrom(http.Header{
+		"Strict-Transport-Security": {"max-age=31536000"},
+		"Content-Security-Policy":   {"default-src 'self'"},
+		"X-Content-Type-Options":    {"nosniff"},
+		"X-Frame-Options":           {"DENY"},
+		"Referrer-Policy":           {"no-referrer"},
+		"Permissions-Policy":        {"camera=()"},
+	})
+
+	pages := map[string]PageData{
+		"example.com": {
+			URL:             "https://example.com",
+			StatusCode:      200,
+			SecurityHeaders: allHeaders,
+			Images: []ImageCandidate{
+				{URL: "http://example.com/logo.png", Source: "src"},
+				{URL: "http://example.com/logo.png", Source: "srcset"},
+				{URL: "https://example.com/safe.png", Source: "src"},
+			},
+			Assets: []Asset{
+				{URL: "http://cdn.example.net/app.js", Type: assetScript},
+				{URL: "http://example.com/clip.mp4", Type: assetVideo},
+			},
+			Links: []Link{
+				{URL: "http://example.com/about", Kind: LinkPage},
+				{URL: "http://other.com/", Kind: LinkPage},
+				{URL: "https://example.com/contact", Kind: LinkPage},
+			},
+		},
+		"example.com/plain": {
+			URL:             "http://example.com/plain",
+			StatusCode:      200,
+			SecurityHeaders: map[string]string{"Content-Security-Policy": "default-src 'self'"},
+			Images:          []ImageCandidate{{URL: "http://example.com/logo.png", Source: "src"}},
+		},
+		"example.com/unfetched": {URL: "https://example.com/unfetched"},
+	}
+
+	type row struct{ Severity, Kind, URL, Target string }
+	var actual []row
+	for _, finding := range analyzeSecurity(pages) {
+		actual = append(actual, row{finding.Severity.String(), finding.Kind, finding.URL, finding.Target})
+	}
+
+	expected := []row{
+		{"error", securityMixedActive, "https://example.com", "http://cdn.example.net/app.js"},
+		{"warning", securityInsecureLink, "https://example.com", "http://example.com/about"},
+		{"warning", securityMixedPassive, "https://example.com", "http://example.com/clip.mp4"},
+		{"warning", securityMixedPassive, "https://example.com", "http://example.com/logo.png"},
+		// Plain HTTP pages aren't checked for mixed content or HSTS
+		{"info", securityMissingHeader, "http://example.com/plain", "Permissions-Policy"},
+		{"info", securityMissingHeader, "http://example.com/plain", "Referrer-Policy"},
+		{"info", securityMissingHeader, "http://example.com/plain", "X-Content-Type-Options"},
+		{"info", securityMissingHeader, "http://example.com/plain", "X-Frame-Options"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, actual)
+	}
+}
+
+func TestAnalyzeSecurityMissingHSTS(t *testing.T) {
+	pages := map[string]PageData{
+		"example.com": {URL: "https://example.com", StatusCode: 200, SecurityHeaders: map[string]string{}},
+	}
+
+	findings := analyzeSecurity(pages)
+	if len(findings) != len(securityHeaderNames) {
+		t.Fatalf("expected one finding per header, got %d", len(findings))
+	}
+	if first := findings[0]; first.Target != "Strict-Transport-Security" || first.Severity != severityWarning {
+		t.Errorf("expected missing HSTS to rank first as a warning, got %+v", first)
+	}
+
+	filename := filepath.Join(t.TempDir(), "security.csv")
+	if err := writeSecurityCSV(findings[:1], filename, CSVOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	actual, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("couldn't read report: %v", err)
+	}
+	expected := "severity,finding,page_url,target,detail\n" +
+		"warning,missing_header,https://example.com,Strict-Transport-Security,\n"
+	if string(actual) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
@@ -0,0 +1,449 @@
+package crawler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// issueSeverity ranks how urgently an issue needs fixing
+type issueSeverity int
+
+const (
+	severityInfo issueSeverity = iota
+	severityWarning
+	severityError
+)
+
+// String returns the severity's name as used in reports and options
+func (s issueSeverity) String() string {
+	switch s {
+	case severityError:
+		return "error"
+	case severityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// parseSeverity converts a severity name into an issueSeverity
+func parseSeverity(raw string) (issueSeverity, error) {
+	switch strings.ToLower(raw) {
+	case "error":
+		return severityError, nil
+	case "warning":
+		return severityWarning, nil
+	case "info":
+		return severityInfo, nil
+	default:
+		return 0, fmt.Errorf("unknown severity %q (expected error, warning or info)", raw)
+	}
+}
+
+// seoIssue is one finding from one rule about one page
+type seoIssue struct {
+	RuleID   string
+	Severity issueSeverity
+	URL      string
+	Message  string
+}
+
+// RuleThresholds holds the configurable limits used by the SEO rules.
+// Lengths are counted in characters.
+type RuleThresholds struct {
+	TitleMinLength       int // Zero means 30
+	TitleMaxLength       int // Zero means 60
+	DescriptionMinLength int // Zero means 70
+	DescriptionMaxLength int // Zero means 160
+}
+
+// WithDefaults fills in any unset thresholds
+func (th RuleThresholds) WithDefaults() RuleThresholds {
+	if th.TitleMinLength == 0 {
+		th.TitleMinLength = 30
+	}
+	if th.TitleMaxLength == 0 {
+		th.TitleMaxLength = 60
+	}
+	if th.DescriptionMinLength == 0 {
+		th.DescriptionMinLength = 70
+	}
+	if th.DescriptionMaxLength == 0 {
+		th.DescriptionMaxLength = 160
+	}
+	return th
+}
+
+// RulesOptions configures the SEO rules run for the issues report
+type RulesOptions struct {
+	Thresholds RuleThresholds
+	Severities map[string]string // Per-rule severity overrides (error, warning or info), keyed by rule ID
+	Disabled   []string          // Rule IDs to skip
+}
+
+// Validate checks that every rule ID and severity is known and that the
+// thresholds make sense
+func (opts RulesOptions) Validate() error {
+	known := make(map[string]bool, len(seoRules))
+	for _, rule := range seoRules {
+		known[rule.ID] = true
+	}
+	for _, id := range opts.Disabled {
+		if !known[id] {
+			return fmt.Errorf("unknown SEO rule %q", id)
+		}
+	}
+	for _, id := range sortedKeys(opts.Severities) {
+		if !known[id] {
+			return fmt.Errorf("unknown SEO rule %q", id)
+		}
+		if _, err := parseSeverity(opts.Severities[id]); err != nil {
+			return fmt.Errorf("rule %s: %w", id, err)
+		}
+	}
+
+	th := opts.Thresholds
+	if th.TitleMinLength < 0 || th.TitleMaxLength < 0 || th.DescriptionMinLength < 0 || th.DescriptionMaxLength < 0 {
+		return fmt.Errorf("rule thresholds must not be negative")
+	}
+	th = th.WithDefaults()
+	if th.TitleMinLength > th.TitleMaxLength {
+		return fmt.Errorf("title minimum length %d is above the maximum %d", th.TitleMinLength, th.TitleMaxLength)
+	}
+	if th.DescriptionMinLength > th.DescriptionMaxLength {
+		return fmt.Errorf("description minimum length %d is above the maximum %d", th.DescriptionMinLength, th.DescriptionMaxLength)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// siteContext is everything a site-wide rule can look at
+type siteContext struct {
+	pages      map[string]PageData
+	sitemap    map[string]bool // Normalized URLs listed in the sitemap
+	normalizer *urlNormalizer  // Turns URLs into page keys
+}
+
+// seoRule is a single check. Page rules run once per page and return one
+// message per problem; site rules see the whole crawl and return issues.
+type seoRule struct {
+	ID          string
+	Severity    issueSeverity
+	Description string
+	checkPage   func(pageData PageData, th RuleThresholds) []string
+	checkSite   func(site siteContext, th RuleThresholds) []seoIssue
+}
+
+// seoRules is the registry of every rule
+var seoRules = []seoRule{
+	{
+		ID:          "title-missing",
+		Severity:    severityError,
+		Description: "Page has no <title>",
+		checkPage: func(p PageData, _ RuleThresholds) []string {
+			if p.Title == "" {
+				return []string{"page has no title"}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "title-too-short",
+		Severity:    severityWarning,
+		Description: "Title is shorter than TitleMinLength characters",
+		checkPage: func(p PageData, th RuleThresholds) []string {
+			return checkMinLength("title", p.Title, th.TitleMinLength)
+		},
+	},
+	{
+		ID:          "title-too-long",
+		Severity:    severityWarning,
+		Description: "Title is longer than TitleMaxLength characters",
+		checkPage: func(p PageData, th RuleThresholds) []string {
+			return checkMaxLength("title", p.Title, th.TitleMaxLength)
+		},
+	},
+	{
+		ID:          "title-duplicate",
+		Severity:    severityWarning,
+		Description: "Several pages share the same title",
+		checkSite:   checkDuplicateTitles,
+	},
+	{
+		ID:          "description-missing",
+		Severity:    severityWarning,
+		Description: "Page has no meta description",
+		checkPage: func(p PageData, _ RuleThresholds) []string {
+			if p.MetaDescription == "" {
+				return []string{"page has no meta description"}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "description-too-short",
+		Severity:    severityInfo,
+		Description: "Meta description is shorter than DescriptionMinLength characters",
+		checkPage: func(p PageData, th RuleThresholds) []string {
+			return checkMinLength("meta description", p.MetaDescription, th.DescriptionMinLength)
+		},
+	},
+	{
+		ID:          "description-too-long",
+		Severity:    severityInfo,
+		Description: "Meta description is longer than DescriptionMaxLength characters",
+		checkPage: func(p PageData, th RuleThresholds) []string {
+			return checkMaxLength("meta description", p.MetaDescription, th.DescriptionMaxLength)
+		},
+	},
+	{
+		ID:          "h1-missing",
+		Severity:    severityWarning,
+		Description: "Page has no <h1>",
+		checkPage: func(p PageData, _ RuleThresholds) []string {
+			if countHeadings(p.Headings, 1) == 0 {
+				return []string{"page has no h1"}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "h1-multiple",
+		Severity:    severityWarning,
+		Description: "Page has more than one <h1>",
+		checkPage: func(p PageData, _ RuleThresholds) []string {
+			if count := countHeadings(p.Headings, 1); count > 1 {
+				return []string{fmt.Sprintf("page has %d h1 headings", count)}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "heading-level-skipped",
+		Severity:    severityInfo,
+		Description: "Heading outline jumps more than one level down, e.g. h2 to h4",
+		checkPage:   checkSkippedHeadings,
+	},
+	{
+		ID:          "img-alt-missing",
+		Severity:    severityWarning,
+		Description: "Images without an alt attribute",
+		checkPage: func(p PageData, _ RuleThresholds) []string {
+			if p.ImagesMissingAlt > 0 {
+				return []string{fmt.Sprintf("%d image(s) have no alt attribute", p.ImagesMissingAlt)}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "canonical-non-200",
+		Severity:    severityError,
+		Description: "Canonical URL points to a crawled page that did not return 200",
+		checkSite:   checkCanonicalStatus,
+	},
+	{
+		ID:          "sitemap-noindex",
+		Severity:    severityError,
+		Description: "Page is listed in the sitemap but marked noindex",
+		checkSite:   checkSitemapNoindex,
+	},
+}
+
+// runSEORules runs every enabled rule over the crawl and returns the issues,
+// most severe first. sitemapURLs may be empty when no sitemap was found,
+// and n is the normalizer the page keys were built with.
+// Unknown severities are ignored; check the options with Validate first.
+func runSEORules(pages map[string]PageData, sitemapURLs []string, n *urlNormalizer, opts RulesOptions) []seoIssue {
+	th := opts.Thresholds.WithDefaults()
+
+	disabled := make(map[string]bool, len(opts.Disabled))
+	for _, id := range opts.Disabled {
+		disabled[id] = true
+	}
+
+	site := siteContext{pages: pages, sitemap: make(map[string]bool, len(sitemapURLs)), normalizer: n}
+	for _, rawURL := range sitemapURLs {
+		if normalized, err := n.normalize(rawURL); err == nil {
+			site.sitemap[normalized] = true
+		}
+	}
+
+	sorted := sortPages(pages, SortByURL)
+
+	var issues []seoIssue
+	for _, rule := range seoRules {
+		if disabled[rule.ID] {
+			continue
+		}
+
+		severity := rule.Severity
+		if override, err := parseSeverity(opts.Severities[rule.ID]); err == nil {
+			severity = override
+		}
+
+		var found []seoIssue
+		if rule.checkPage != nil {
+			for _, pageData := range sorted {
+				if !isRuleCandidate(pageData) {
+					continue
+				}
+				for _, message := range rule.checkPage(pageData, th) {
+					found = append(found, seoIssue{URL: pageData.URL, Message: message})
+				}
+			}
+		}
+		if rule.checkSite != nil {
+			found = append(found, rule.checkSite(site, th)...)
+		}
+
+		for _, issue := range found {
+			issue.RuleID = rule.ID
+			issue.Severity = severity
+			issues = append(issues, issue)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Severity != issues[j].Severity {
+			return issues[i].Severity > issues[j].Severity
+		}
+		if issues[i].RuleID != issues[j].RuleID {
+			return issues[i].RuleID < issues[j].RuleID
+		}
+		return issues[i].URL < issues[j].URL
+	})
+
+	return issues
+}
+
+// isRuleCandidate reports whether a page's content was extracted, so content
+// rules apply. Broken pages have nothing to check.
+func isRuleCandidate(pageData PageData) bool {
+	return pageData.StatusCode < 300
+}
+
+// checkMinLength flags a non-empty value shorter than min characters
+func checkMinLength(what, value string, min int) []string {
+	if length := utf8.RuneCountInString(value); length > 0 && length < min {
+		return []string{fmt.Sprintf("%s is %d characters (minimum %d)", what, length, min)}
+	}
+	return nil
+}
+
+// checkMaxLength flags a value longer than max characters
+func checkMaxLength(what, value string, max int) []string {
+	if length := utf8.RuneCountInString(value); length > max {
+		return []string{fmt.Sprintf("%s is %d characters (maximum %d)", what, length, max)}
+	}
+	return nil
+}
+
+// countHeadings counts the headings at the given level
+func countHeadings(headings []Heading, level int) int {
+	count := 0
+	for _, heading := range headings {
+		if heading.Level == level {
+			count++
+		}
+	}
+	return count
+}
+
+// checkSkippedHeadings flags each place the outline jumps down more than one level
+func checkSkippedHeadings(p PageData, _ RuleThresholds) []string {
+	var messages []string
+	previous := 0
+	for _, heading := range p.Headings {
+		if previous > 0 && heading.Level > previous+1 {
+			messages = append(messages, fmt.Sprintf("h%d %q follows h%d", heading.Level, heading.Text, previous))
+		}
+		previous = heading.Level
+	}
+	return messages
+}
+
+// checkDuplicateTitles reports every page whose title is shared with another page
+func checkDuplicateTitles(site siteContext, _ RuleThresholds) []seoIssue {
+	byTitle := make(map[string][]string)
+	for _, pageData := range sortPages(site.pages, SortByURL) {
+		if pageData.Title == "" || !isRuleCandidate(pageData) {
+			continue
+		}
+		byTitle[pageData.Title] = append(byTitle[pageData.Title], pageData.URL)
+	}
+
+	var issues []seoIssue
+	for title, urls := range byTitle {
+		if len(urls) < 2 {
+			continue
+		}
+		for _, u := range urls {
+			issues = append(issues, seoIssue{
+				URL:     u,
+				Message: fmt.Sprintf("title %q is shared by %d pages", title, len(urls)),
+			})
+		}
+	}
+	return issues
+}
+
+// checkCanonicalStatus flags canonicals that point to a crawled page with a non-200 status
+func checkCanonicalStatus(site siteContext, _ RuleThresholds) []seoIssue {
+	var issues []seoIssue
+	for _, pageData := range sortPages(site.pages, SortByURL) {
+		if pageData.Canonical == "" {
+			continue
+		}
+		target, err := site.normalizer.normalize(pageData.Canonical)
+		if err != nil {
+			continue
+		}
+		canonicalPage, crawled := site.pages[target]
+		if !crawled || canonicalPage.StatusCode == 200 {
+			continue
+		}
+		issues = append(issues, seoIssue{
+			URL:     pageData.URL,
+			Message: fmt.Sprintf("canonical %s returned status %d", pageData.Canonical, canonicalPage.StatusCode),
+		})
+	}
+	return issues
+}
+
+// checkSitemapNoindex flags noindex pages that the sitemap asks to be indexed
+func checkSitemapNoindex(site siteContext, _ RuleThresholds) []seoIssue {
+	var issues []seoIssue
+	for key, pageData := range site.pages {
+		if site.sitemap[key] && pageData.Robots.NoIndex {
+			issues = append(issues, seoIssue{
+				URL:     pageData.URL,
+				Message: fmt.Sprintf("listed in the sitemap but marked %s", pageData.Robots),
+			})
+		}
+	}
+	return issues
+}
+
+// issuesHeader is the column layout of the issues report
+var issuesHeader = []string{"severity", "rule_id", "page_url", "message"}
+
+// writeIssuesCSV writes the issues report in the order runSEORules returned them
+func writeIssuesCSV(issues []seoIssue, filename string, opts CSVOptions) error {
+	rows := make([][]string, len(issues))
+	for i, issue := range issues {
+		rows[i] = []string{issue.Severity.String(), issue.RuleID, issue.URL, issue.Message}
+	}
+
+	return writeCSVRows(filename, opts, issuesHeader, rows)
+}
@@ -0,0 +1,204 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// issueKeys flattens issues into "rule_id page_url" strings for comparison
+func issueKeys(issues []seoIssue) []string {
+	var keys []string
+	for _, issue := range issues {
+		keys = append(keys, issue.RuleID+" "+issue.URL)
+	}
+	return keys
+}
+
+func TestRunSEORulesPageRules(t *testing.T) {
+	good := PageData{
+		URL:             "https://example.com/good",
+		StatusCode:      200,
+		Title:           "A perfectly reasonable page title here",
+		MetaDescription: strings.Repeat("d", 100),
+		Headings:        []Heading{{1, "Title"}, {2, "Section"}, {3, "Sub"}, {2, "Next"}},
+	}
+	bad := PageData{
+		URL:              "https://example.com/bad",
+		StatusCode:       200,
+		Title:            strings.Repeat("t", 70),
+		MetaDescription:  "short",
+		Headings:         []Heading{{1, "One"}, {1, "Two"}, {4, "Deep"}},
+		ImagesMissingAlt: 2,
+	}
+	empty := PageData{URL: "https://example.com/empty", StatusCode: 200}
+	broken := PageData{URL: "https://example.com/broken", StatusCode: 404}
+
+	pages := map[string]PageData{
+		"example.com/good":   good,
+		"example.com/bad":    bad,
+		"example.com/empty":  empty,
+		"example.com/broken": broken,
+	}
+
+	issues := runSEORules(pages, nil, nil, RulesOptions{})
+
+	expected := []string{
+		"title-missing https://example.com/empty",
+		"description-missing https://example.com/empty",
+		"h1-missing https://example.com/empty",
+		"h1-multiple https://example.com/bad",
+		"img-alt-missing https://example.com/bad",
+		"title-too-long https://example.com/bad",
+		"description-too-short https://example.com/bad",
+		"heading-level-skipped https://example.com/bad",
+	}
+	actual := issueKeys(issues)
+	sort.Strings(actual)
+	sort.Strings(expected)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	// Most severe first
+	for i := 1; i < len(issues); i++ {
+		if issues[i].Severity > issues[i-1].Severity {
+			t.Fatalf("issues are not ranked by severity: %+v", issues)
+		}
+	}
+}
+
+func TestRunSEORulesSiteRules(t *testing.T) {
+	pages := map[string]PageData{
+		"example.com/a": {
+			URL:        "https://example.com/a",
+			StatusCode: 200,
+			Title:      "Shared title",
+			Canonical:  "https://example.com/gone",
+		},
+		"example.com/b": {
+			URL:        "https://example.com/b",
+			StatusCode: 200,
+			Title:      "Shared title",
+			Robots:     RobotsDirectives{NoIndex: true},
+		},
+		"example.com/gone": {URL: "https://example.com/gone", StatusCode: 404},
+	}
+
+	opts := RulesOptions{
+		Disabled: []string{
+			"title-too-short", "description-missing", "h1-missing",
+		},
+		Severities: map[string]string{"title-duplicate": "error"},
+	}
+	issues := runSEORules(pages, []string{"https://example.com/b/"}, nil, opts)
+
+	expected := []string{
+		"canonical-non-200 https://example.com/a",
+		"sitemap-noindex https://example.com/b",
+		"title-duplicate https://example.com/a",
+		"title-duplicate https://example.com/b",
+	}
+	if actual := issueKeys(issues); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	for _, issue := range issues {
+		if issue.RuleID == "title-duplicate" && issue.Severity != severityError {
+			t.Errorf("expected severity override to apply, got %v", issue.Severity)
+		}
+	}
+}
+
+func TestRunSEORulesThresholds(t *testing.T) {
+	pages := map[string]PageData{
+		"example.com": {URL: "https://example.com", StatusCode: 200, Title: "Twelve chars"},
+	}
+
+	opts := RulesOptions{
+		Thresholds: RuleThresholds{TitleMinLength: 5, TitleMaxLength: 10},
+		Disabled:   []string{"description-missing", "h1-missing"},
+	}
+	expected := []string{"title-too-long https://example.com"}
+	if actual := issueKeys(runSEORules(pages, nil, nil, opts)); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestRulesOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    RulesOptions
+		message string // Empty when the options are valid
+	}{
+		{name: "defaults", opts: RulesOptions{}},
+		{name: "overrides", opts: RulesOptions{Severities: map[string]string{"h1-multiple": "Error"}, Disabled: []string{"img-alt-missing"}}},
+		{name: "unknown disabled rule", opts: RulesOptions{Disabled: []string{"h7-missing"}}, message: `unknown SEO rule "h7-missing"`},
+		{name: "unknown severity", opts: RulesOptions{Severities: map[string]string{"h1-missing": "fatal"}}, message: `rule h1-missing: unknown severity "fatal"`},
+		{name: "negative threshold", opts: RulesOptions{Thresholds: RuleThresholds{TitleMaxLength: -1}}, message: "must not be negative"},
+		{name: "minimum above maximum", opts: RulesOptions{Thresholds: RuleThresholds{DescriptionMinLength: 200}}, message: "description minimum length 200 is above the maximum 160"},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.opts.Validate()
+			if tc.message == "" && err != nil {
+				t.Errorf("Test %v - %s FAIL: unexpected error: %v", i, tc.name, err)
+			}
+			if tc.message != "" && (err == nil || !strings.Contains(err.Error(), tc.message)) {
+				t.Errorf("Test %v - %s FAIL: expected error containing %q, got %v", i, tc.name, tc.message, err)
+			}
+		})
+	}
+}
+
+func TestSeoRuleIDsAreUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, rule := range seoRules {
+		if seen[rule.ID] {
+			t.Errorf("duplicate rule ID %q", rule.ID)
+		}
+		seen[rule.ID] = true
+		if (rule.checkPage == nil) == (rule.checkSite == nil) {
+			t.Errorf("rule %q must have exactly one check", rule.ID)
+		}
+	}
+}
+
+func TestFetchSitemapURLsFollowsIndex(t *testing.T) {
+	var serverURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+				<sitemap><loc>%s/posts.xml</loc></sitemap>
+			</sitemapindex>`, serverURL)
+		case "/posts.xml":
+			fmt.Fprintf(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+				<url><loc> %s/one </loc></url>
+				<url><loc>%s/two</loc></url>
+			</urlset>`, serverURL, serverURL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	serverURL = server.URL
+
+	fetcher, _ := newHTTPFetcher(FetchOptions{})
+	actual, err := fetchSitemapURLs(context.Background(), fetcher, server.URL+"/sitemap.xml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{server.URL + "/one", server.URL + "/two"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,88 @@
+package crawler
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// maxSitemapFiles caps how many sitemap files a sitemap index can pull in
+const maxSitemapFiles = 50
+
+// sitemapDoc covers both <urlset> sitemaps and <sitemapindex> files
+type sitemapDoc struct {
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// defaultSitemapURL returns the conventional /sitemap.xml location for a site
+func defaultSitemapURL(baseURL *url.URL) string {
+	return baseURL.ResolveReference(&url.URL{Path: "/sitemap.xml"}).String()
+}
+
+// fetchSitemapURLs returns every page URL listed in a sitemap, following
+// sitemap index files up to maxSitemapFiles in total
+func fetchSitemapURLs(ctx context.Context, fetcher Fetcher, sitemapURL string) ([]string, error) {
+	var pageURLs []string
+	visited := make(map[string]bool)
+	queue := []string{sitemapURL}
+
+	for len(queue) > 0 && len(visited) < maxSitemapFiles {
+		current := queue[0]
+		queue = queue[1:]
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+
+		body, err := fetchSitemapFile(ctx, fetcher, current)
+		if err != nil {
+			return pageURLs, err
+		}
+
+		doc, err := parseSitemap(body)
+		if err != nil {
+			return pageURLs, fmt.Errorf("couldn't parse sitemap %s: %w", current, err)
+		}
+
+		for _, entry := range doc.URLs {
+			if loc := strings.TrimSpace(entry.Loc); loc != "" {
+				pageURLs = append(pageURLs, loc)
+			}
+		}
+		for _, entry := range doc.Sitemaps {
+			if loc := strings.TrimSpace(entry.Loc); loc != "" {
+				queue = append(queue, loc)
+			}
+		}
+	}
+
+	return pageURLs, nil
+}
+
+// parseSitemap decodes a sitemap or sitemap index
+func parseSitemap(body []byte) (sitemapDoc, error) {
+	var doc sitemapDoc
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return sitemapDoc{}, err
+	}
+	return doc, nil
+}
+
+// fetchSitemapFile downloads a single sitemap file
+func fetchSitemapFile(ctx context.Context, fetcher Fetcher, rawURL string) ([]byte, error) {
+	res, err := fetcher.Fetch(ctx, rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
+	}
+	if res.StatusCode >= 400 {
+		return nil, fmt.Errorf("failed to fetch sitemap %s: status code %d", rawURL, res.StatusCode)
+	}
+	return []byte(res.Body), nil
+}
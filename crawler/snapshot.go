@@ -0,0 +1,53 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// SnapshotFilename is where crawl saves its results for report and diff
+const SnapshotFilename = "crawl.json"
+
+// Snapshot is everything a crawl found, saved so reports can be
+// regenerated and crawls compared without fetching the site again
+type Snapshot struct {
+	BaseURL     string              `json:"base_url"`
+	CrawledAt   time.Time           `json:"crawled_at"`
+	SitemapURLs []string            `json:"sitemap_urls,omitempty"`
+	Fields      []string            `json:"fields,omitempty"` // Custom fields set by extractors, in column order
+	Normalize   NormalizeOptions    `json:"normalize"`        // How the page keys were built
+	Pages       map[string]PageData `json:"pages"`            // Keyed by normalized URL
+}
+
+// SaveSnapshot writes the snapshot as indented JSON. Map keys are sorted
+// by encoding/json, so the same crawl always produces the same file.
+func SaveSnapshot(snapshot Snapshot, filename string) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("couldn't encode snapshot: %w", err)
+	}
+
+	if err := os.WriteFile(filename, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("couldn't write snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot reads a snapshot written by SaveSnapshot
+func LoadSnapshot(filename string) (Snapshot, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("couldn't read snapshot: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return Snapshot{}, fmt.Errorf("couldn't parse snapshot %s: %w", filename, err)
+	}
+	if snapshot.Pages == nil {
+		snapshot.Pages = map[string]PageData{}
+	}
+	return snapshot, nil
+}
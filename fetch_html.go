@@ -1,50 +0,0 @@
-package main
-
-import (
-	"fmt"
-	"io"
-	"net/http"
-	"strings"
-)
-
-func getHTML(rawURL string) (string, error) {
-	// Create HTTP client
-	client := &http.Client{}
-
-	// Create GET request
-	req, err := http.NewRequest("GET", rawURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set User-Agent header to identify our crawler
-	req.Header.Set("User-Agent", "BootCrawler/1.0")
-
-	// Execute the request
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to fetch URL: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check for HTTP error status codes (400+)
-	if resp.StatusCode >= 400 {
-		return "", fmt.Errorf("HTTP error: status code %d", resp.StatusCode)
-	}
-
-	// Check Content-Type header
-	contentType := resp.Header.Get("Content-Type")
-	if !strings.HasPrefix(contentType, "text/html") {
-		return "", fmt.Errorf("invalid content type: %s, expected text/html", contentType)
-	}
-
-	// Read the response body
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Convert to string and return
-	return string(bodyBytes), nil
-}
-
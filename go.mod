@@ -3,7 +3,11 @@ module github.com/Utkarsh736/linkscout
 go 1.24.0
 
 require (
-	github.com/PuerkitoBio/goquery v1.11.0 // indirect
-	github.com/andybalholm/cascadia v1.3.3 // indirect
-	golang.org/x/net v0.47.0 // indirect
+	github.com/BurntSushi/toml v1.6.0
+	github.com/PuerkitoBio/goquery v1.11.0
+	github.com/andybalholm/cascadia v1.3.3
+	golang.org/x/net v0.47.0
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require golang.org/x/text v0.31.0 // indirect
@@ -1,105 +1,7 @@
 package main
 
-import (
-	"fmt"
-	"net/url"
-	"os"
-	"strconv"
-	"sync"
-)
+import "os"
 
 func main() {
-	// Get command line arguments
-	args := os.Args[1:]
-
-	// Validate number of arguments
-	if len(args) < 3 {
-		fmt.Println("not enough arguments provided")
-		fmt.Println("usage: crawler <URL> <maxConcurrency> <maxPages>")
-		os.Exit(1)
-	}
-
-	if len(args) > 3 {
-		fmt.Println("too many arguments provided")
-		fmt.Println("usage: crawler <URL> <maxConcurrency> <maxPages>")
-		os.Exit(1)
-	}
-
-	// Parse arguments
-	rawBaseURL := args[0]
-
-	maxConcurrency, err := strconv.Atoi(args[1])
-	if err != nil {
-		fmt.Printf("error parsing maxConcurrency: %v\n", err)
-		os.Exit(1)
-	}
-
-	maxPages, err := strconv.Atoi(args[2])
-	if err != nil {
-		fmt.Printf("error parsing maxPages: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Validate values
-	if maxConcurrency < 1 {
-		fmt.Println("maxConcurrency must be at least 1")
-		os.Exit(1)
-	}
-
-	if maxPages < 1 {
-		fmt.Println("maxPages must be at least 1")
-		os.Exit(1)
-	}
-
-	// Parse the base URL
-	baseURL, err := url.Parse(rawBaseURL)
-	if err != nil {
-		fmt.Printf("error parsing URL: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Print start message
-	fmt.Printf("starting crawl of: %s\n", rawBaseURL)
-	fmt.Printf("max concurrency: %d\n", maxConcurrency)
-	fmt.Printf("max pages: %d\n", maxPages)
-	fmt.Println()
-
-	// Configure the crawler
-	cfg := &config{
-		pages:              make(map[string]PageData),
-		baseURL:            baseURL,
-		mu:                 &sync.Mutex{},
-		concurrencyControl: make(chan struct{}, maxConcurrency),
-		wg:                 &sync.WaitGroup{},
-		maxPages:           maxPages,
-	}
-
-	// Start the first crawl
-	cfg.wg.Add(1)
-	go func() {
-		defer cfg.wg.Done()
-		cfg.concurrencyControl <- struct{}{} // Acquire semaphore
-		defer func() { <-cfg.concurrencyControl }() // Release semaphore
-
-		cfg.crawlPage(rawBaseURL)
-	}()
-
-	// Wait for all goroutines to finish
-	cfg.wg.Wait()
-
-	// Print completion message
-	fmt.Println("\n=============================")
-	fmt.Println("CRAWL COMPLETE")
-	fmt.Println("=============================")
-	fmt.Printf("Found %d unique pages\n\n", len(cfg.pages))
-
-	// Write CSV report
-	fmt.Println("Writing report to report.csv...")
-	err = writeCSVReport(cfg.pages, "report.csv")
-	if err != nil {
-		fmt.Printf("Error writing CSV report: %v\n", err)
-		os.Exit(1)
-	}
-
-	fmt.Println("Report successfully written to report.csv")
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
 }
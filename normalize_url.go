@@ -1,20 +0,0 @@
-package main
-
-import (
-	"fmt"
-	"net/url"
-	"strings"
-)
-
-func normalizeURL(inputURL string) (string, error) {
-	parsedURL, err := url.Parse(inputURL)
-	if err != nil {
-		return "", fmt.Errorf("couldn't parse URL: %w", err)
-	}
-
-	fullPath := parsedURL.Host + parsedURL.Path
-	fullPath = strings.ToLower(fullPath)
-	fullPath = strings.TrimRight(fullPath, "/") 
-
-	return fullPath, nil
-}
@@ -1,71 +0,0 @@
-package main
-
-import "testing"
-
-func TestNormalizeURL(t *testing.T) {
-	tests := []struct {
-		name     string
-		inputURL string
-		expected string
-	}{
-		{
-			name:     "remove https scheme",
-			inputURL: "https://blog.boot.dev/path",
-			expected: "blog.boot.dev/path",
-		},
-		{
-			name:     "remove http scheme",
-			inputURL: "http://blog.boot.dev/path",
-			expected: "blog.boot.dev/path",
-		},
-		{
-			name:     "remove trailing slash",
-			inputURL: "https://blog.boot.dev/path/",
-			expected: "blog.boot.dev/path",
-		},
-		{
-			name:     "remove scheme and trailing slash",
-			inputURL: "http://blog.boot.dev/path/",
-			expected: "blog.boot.dev/path",
-		},
-		{
-			name:     "handle domain without path",
-			inputURL: "https://blog.boot.dev",
-			expected: "blog.boot.dev",
-		},
-		{
-			name:     "handle domain without path but with trailing slash",
-			inputURL: "https://blog.boot.dev/",
-			expected: "blog.boot.dev",
-		},
-		{
-			name:     "handle multiple trailing slashes",
-			inputURL: "https://blog.boot.dev/path///",
-			expected: "blog.boot.dev/path",
-		},
-		{
-			name:     "lowercase domain",
-			inputURL: "https://BLOG.boot.dev/path",
-			expected: "blog.boot.dev/path",
-		},
-		{
-			name:     "handle url with port",
-			inputURL: "http://blog.boot.dev:8080/path",
-			expected: "blog.boot.dev:8080/path",
-		},
-	}
-
-	for i, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			actual, err := normalizeURL(tc.inputURL)
-			if err != nil {
-				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
-				return
-			}
-			if actual != tc.expected {
-				t.Errorf("Test %v - %s FAIL: expected URL: %v, actual: %v", i, tc.name, tc.expected, actual)
-			}
-		})
-	}
-}
-
@@ -0,0 +1,918 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/http/httpguts"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Utkarsh736/linkscout/crawler"
+)
+
+// profileFormat is the file format of a crawl profile
+type profileFormat string
+
+const (
+	profileYAML profileFormat = "yaml"
+	profileTOML profileFormat = "toml"
+)
+
+// parseProfileFormat converts a user-supplied format name into a profileFormat
+func parseProfileFormat(raw string) (profileFormat, error) {
+	switch format := profileFormat(raw); format {
+	case "":
+		return profileYAML, nil
+	case profileYAML, profileTOML:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown profile format %q (expected yaml or toml)", raw)
+	}
+}
+
+// profileFormatFor picks the format from a profile's file extension
+func profileFormatFor(path string) (profileFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return profileYAML, nil
+	case ".toml":
+		return profileTOML, nil
+	default:
+		return "", fmt.Errorf("can't tell the format of %s (use a .yaml, .yml or .toml extension)", path)
+	}
+}
+
+// crawlProfile is a named set of crawl settings, loaded from a YAML or TOML
+// file. Every setting is optional: nil pointers and slices keep the flag
+// default. Keys are the same in both formats.
+type crawlProfile struct {
+	URL       *string           `yaml:"url,omitempty" toml:"url,omitempty"`
+	Crawl     profileCrawl      `yaml:"crawl" toml:"crawl"`
+	Fetch     profileFetch      `yaml:"fetch" toml:"fetch"`
+	Normalize profileNormalize  `yaml:"normalize" toml:"normalize"`
+	Report    profileReport     `yaml:"report" toml:"report"`
+	Rules     profileRules      `yaml:"rules" toml:"rules"`
+	Login     profileLogin      `yaml:"login,omitempty" toml:"login,omitempty"`
+	Extract   map[string]string `yaml:"extract,omitempty" toml:"extract,omitempty"` // Custom field name to CSS selector
+}
+
+// profileCrawl holds the crawl scope and limits
+type profileCrawl struct {
+	Concurrency *int     `yaml:"concurrency,omitempty" toml:"concurrency,omitempty"`
+	MaxPages    *int     `yaml:"max_pages,omitempty" toml:"max_pages,omitempty"`
+	Robots      *string  `yaml:"robots,omitempty" toml:"robots,omitempty"`
+	Stream      *bool    `yaml:"stream,omitempty" toml:"stream,omitempty"`
+	Include     []string `yaml:"include,omitempty" toml:"include,omitempty"` // Regular expressions; a URL must match one
+	Exclude     []string `yaml:"exclude,omitempty" toml:"exclude,omitempty"` // Regular expressions; a URL must match none
+}
+
+// profileFetch holds the HTTP request settings
+type profileFetch struct {
+	UserAgent   *string           `yaml:"user_agent,omitempty" toml:"user_agent,omitempty"`
+	Headers     map[string]string `yaml:"headers,omitempty" toml:"headers,omitempty"`
+	Timeout     *string           `yaml:"timeout,omitempty" toml:"timeout,omitempty"` // Go duration, e.g. "30s"
+	RateLimit   *float64          `yaml:"rate_limit,omitempty" toml:"rate_limit,omitempty"`
+	CookiesFile *string           `yaml:"cookies_file,omitempty" toml:"cookies_file,omitempty"`
+	Auth        []profileAuth     `yaml:"auth,omitempty" toml:"auth,omitempty"`
+}
+
+// profileAuth names where a host's credentials are kept. Profiles never
+// hold the secret itself.
+type profileAuth struct {
+	Host string `yaml:"host" toml:"host"`
+	Type string `yaml:"type" toml:"type"`
+	Env  string `yaml:"env,omitempty" toml:"env,omitempty"`
+	File string `yaml:"file,omitempty" toml:"file,omitempty"`
+}
+
+// spec converts the profile entry into an AuthSpec
+func (a profileAuth) spec() (crawler.AuthSpec, error) {
+	scheme, err := crawler.ParseAuthScheme(a.Type)
+	if err != nil {
+		return crawler.AuthSpec{}, err
+	}
+	spec := crawler.AuthSpec{Host: a.Host, Scheme: scheme, Env: a.Env, File: a.File}
+	return spec, spec.Validate()
+}
+
+// profileLogin mirrors LoginOptions. Secret fields name an "env:VAR" or
+// "file:PATH" source, never the value.
+type profileLogin struct {
+	URL             *string           `yaml:"url,omitempty" toml:"url,omitempty"`
+	Fields          map[string]string `yaml:"fields,omitempty" toml:"fields,omitempty"`
+	SecretFields    map[string]string `yaml:"secret_fields,omitempty" toml:"secret_fields,omitempty"`
+	SuccessURL      *string           `yaml:"success_url,omitempty" toml:"success_url,omitempty"`
+	SuccessSelector *string           `yaml:"success_selector,omitempty" toml:"success_selector,omitempty"`
+}
+
+// profileNormalize mirrors NormalizeOptions
+type profileNormalize struct {
+	Scheme          *string  `yaml:"scheme,omitempty" toml:"scheme,omitempty"`
+	TrailingSlash   *string  `yaml:"trailing_slash,omitempty" toml:"trailing_slash,omitempty"`
+	IDN             *string  `yaml:"idn,omitempty" toml:"idn,omitempty"`
+	KeepDefaultPort *bool    `yaml:"keep_default_port,omitempty" toml:"keep_default_port,omitempty"`
+	KeepDotSegments *bool    `yaml:"keep_dot_segments,omitempty" toml:"keep_dot_segments,omitempty"`
+	KeepEncoding    *bool    `yaml:"keep_encoding,omitempty" toml:"keep_encoding,omitempty"`
+	KeepQueryOrder  *bool    `yaml:"keep_query_order,omitempty" toml:"keep_query_order,omitempty"`
+	DropQuery       *bool    `yaml:"drop_query,omitempty" toml:"drop_query,omitempty"`
+	KeepFragment    *bool    `yaml:"keep_fragment,omitempty" toml:"keep_fragment,omitempty"`
+	TrackingParams  []string `yaml:"tracking_params,omitempty" toml:"tracking_params,omitempty"` // Empty list keeps every parameter
+}
+
+// profileReport holds the output settings
+type profileReport struct {
+	OutputDir          *string  `yaml:"output_dir,omitempty" toml:"output_dir,omitempty"`
+	Columns            []string `yaml:"columns,omitempty" toml:"columns,omitempty"`
+	Delimiter          *string  `yaml:"delimiter,omitempty" toml:"delimiter,omitempty"`
+	MultiSeparator     *string  `yaml:"multi_separator,omitempty" toml:"multi_separator,omitempty"`
+	LongFormat         *bool    `yaml:"long_format,omitempty" toml:"long_format,omitempty"`
+	BOM                *bool    `yaml:"bom,omitempty" toml:"bom,omitempty"`
+	Sort               *string  `yaml:"sort,omitempty" toml:"sort,omitempty"`
+	CollapseCanonicals *bool    `yaml:"collapse_canonicals,omitempty" toml:"collapse_canonicals,omitempty"`
+	ProbeImages        *bool    `yaml:"probe_images,omitempty" toml:"probe_images,omitempty"`
+	CollapseQuery      *bool    `yaml:"collapse_query,omitempty" toml:"collapse_query,omitempty"`
+	SelfLoops          *bool    `yaml:"self_loops,omitempty" toml:"self_loops,omitempty"`
+	Damping            *float64 `yaml:"damping,omitempty" toml:"damping,omitempty"`
+	PageRankIterations *int     `yaml:"pagerank_iterations,omitempty" toml:"pagerank_iterations,omitempty"`
+	PageRankTolerance  *float64 `yaml:"pagerank_tolerance,omitempty" toml:"pagerank_tolerance,omitempty"`
+	Nofollow           *string  `yaml:"nofollow,omitempty" toml:"nofollow,omitempty"`
+	DuplicateThreshold *float64 `yaml:"duplicate_threshold,omitempty" toml:"duplicate_threshold,omitempty"`
+	MaxImageBytes      *int64   `yaml:"max_image_bytes,omitempty" toml:"max_image_bytes,omitempty"`
+}
+
+// profileRules configures the SEO rules of the issues report
+type profileRules struct {
+	Disabled             []string          `yaml:"disabled,omitempty" toml:"disabled,omitempty"`
+	Severity             map[string]string `yaml:"severity,omitempty" toml:"severity,omitempty"` // Rule ID to error, warning or info
+	TitleMinLength       *int              `yaml:"title_min_length,omitempty" toml:"title_min_length,omitempty"`
+	TitleMaxLength       *int              `yaml:"title_max_length,omitempty" toml:"title_max_length,omitempty"`
+	DescriptionMinLength *int              `yaml:"description_min_length,omitempty" toml:"description_min_length,omitempty"`
+	DescriptionMaxLength *int              `yaml:"description_max_length,omitempty" toml:"description_max_length,omitempty"`
+}
+
+// profileError is one problem found in a profile file. Line is 0 when
+// the position isn't known.
+type profileError struct {
+	File    string
+	Line    int
+	Key     string
+	Message string
+}
+
+func (e profileError) Error() string {
+	position := e.File
+	if e.Line > 0 {
+		position = fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+	if e.Key == "" {
+		return fmt.Sprintf("%s: %s", position, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", position, e.Key, e.Message)
+}
+
+// profileErrors collects every problem in a profile, so they can all be
+// fixed in one go
+type profileErrors []profileError
+
+func (errs profileErrors) Error() string {
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// profileSource is a decoded profile file along with the line of each key
+type profileSource struct {
+	file  string
+	keys  []string       // Every dotted key in the file, tables included
+	lines map[string]int // Line of each dotted key, where known
+}
+
+// errorAt builds an error for a key, looking up its line
+func (src profileSource) errorAt(key, format string, args ...any) profileError {
+	return profileError{File: src.file, Line: src.lines[key], Key: key, Message: fmt.Sprintf(format, args...)}
+}
+
+// loadProfile reads a YAML or TOML profile and checks it against the
+// schema. Unknown keys, wrong types and invalid values are all reported,
+// each with its line number.
+func loadProfile(path string) (crawlProfile, error) {
+	format, err := profileFormatFor(path)
+	if err != nil {
+		return crawlProfile{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return crawlProfile{}, fmt.Errorf("couldn't read profile: %w", err)
+	}
+	return parseProfile(data, path, format)
+}
+
+// parseProfile decodes and validates a profile. file only labels errors.
+func parseProfile(data []byte, file string, format profileFormat) (crawlProfile, error) {
+	var profile crawlProfile
+	var src profileSource
+	var err error
+	switch format {
+	case profileTOML:
+		src, err = decodeTOMLProfile(data, file, &profile)
+	default:
+		src, err = decodeYAMLProfile(data, file, &profile)
+	}
+
+	// Unknown keys are reported alongside type errors, or invalid values
+	// once every value has the right type
+	errs := src.unknownKeys()
+	var decodeErrs profileErrors
+	switch {
+	case errors.As(err, &decodeErrs):
+		errs = append(errs, decodeErrs...)
+	case err != nil:
+		return crawlProfile{}, err
+	default:
+		errs = append(errs, validateProfile(profile, src)...)
+	}
+
+	if len(errs) > 0 {
+		sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+		return crawlProfile{}, errs
+	}
+	return profile, nil
+}
+
+// yamlLinePattern finds the line number yaml.v3 puts in its error messages
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// decodeYAMLProfile decodes a YAML profile, recording the line of every key
+func decodeYAMLProfile(data []byte, file string, profile *crawlProfile) (profileSource, error) {
+	src := profileSource{file: file, lines: make(map[string]int)}
+
+	// An empty file is an empty profile
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return src, yamlErrors(err, file)
+	}
+	if len(root.Content) == 0 {
+		return src, nil
+	}
+	if root.Content[0].Kind != yaml.MappingNode {
+		return src, profileErrors{{File: file, Line: root.Content[0].Line, Message: "expected a mapping of settings"}}
+	}
+	src.collectYAMLKeys(root.Content[0], "")
+
+	if err := root.Decode(profile); err != nil {
+		return src, yamlErrors(err, file)
+	}
+	return src, nil
+}
+
+// collectYAMLKeys records the dotted path and line of every mapping key.
+// Keys of mappings inside lists are recorded without an index.
+func (src *profileSource) collectYAMLKeys(node *yaml.Node, prefix string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := prefix + keyNode.Value
+		src.keys = append(src.keys, key)
+		src.lines[key] = keyNode.Line
+		switch valueNode.Kind {
+		case yaml.MappingNode:
+			src.collectYAMLKeys(valueNode, key+".")
+		case yaml.SequenceNode:
+			for _, item := range valueNode.Content {
+				if item.Kind == yaml.MappingNode {
+					src.collectYAMLKeys(item, key+".")
+				}
+			}
+		}
+	}
+}
+
+// yamlErrors splits a yaml.v3 error into line-numbered profile errors
+func yamlErrors(err error, file string) error {
+	messages := []string{err.Error()}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+
+	errs := make(profileErrors, 0, len(messages))
+	for _, message := range messages {
+		profileErr := profileError{File: file, Message: message}
+		if match := yamlLinePattern.FindStringSubmatch(message); match != nil {
+			profileErr.Line, _ = strconv.Atoi(match[1])
+			profileErr.Message = match[2]
+		}
+		errs = append(errs, profileErr)
+	}
+	return errs
+}
+
+// tomlTypeErrorPattern finds the line and key in the TOML decoder's type errors
+var tomlTypeErrorPattern = regexp.MustCompile(`^toml: line (\d+) \(last key "([^"]*)"\): (.*)$`)
+
+// decodeTOMLProfile decodes a TOML profile, recording the line of every key
+func decodeTOMLProfile(data []byte, file string, profile *crawlProfile) (profileSource, error) {
+	src := profileSource{file: file, lines: tomlKeyLines(data)}
+
+	meta, err := toml.Decode(string(data), profile)
+	var parseErr toml.ParseError
+	if errors.As(err, &parseErr) {
+		return src, profileErrors{{File: file, Line: parseErr.Position.Line, Key: parseErr.LastKey, Message: parseErr.Message}}
+	}
+	if err != nil {
+		profileErr := profileError{File: file, Message: err.Error()}
+		if match := tomlTypeErrorPattern.FindStringSubmatch(err.Error()); match != nil {
+			profileErr.Line, _ = strconv.Atoi(match[1])
+			profileErr.Key = match[2]
+			profileErr.Message = match[3]
+		}
+		return src, profileErrors{profileErr}
+	}
+
+	for _, key := range meta.Keys() {
+		src.keys = append(src.keys, strings.Join(key, "."))
+	}
+	return src, nil
+}
+
+// tomlKeyLines finds the line of each key assignment and table header. The
+// TOML decoder doesn't expose positions, so this scans the lines itself.
+// Keys of one-line inline tables are found; keys inside multi-line values
+// aren't.
+func tomlKeyLines(data []byte) map[string]int {
+	lines := make(map[string]int)
+	table := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "["):
+			end := strings.Index(line, "]")
+			if end < 0 {
+				continue
+			}
+			table = tomlKeyPath(strings.Trim(line[:end], "[]")) // [[array]] tables too
+			lines[table] = lineNo
+		default:
+			eq := strings.Index(line, "=")
+			if eq < 0 {
+				continue
+			}
+			key := tomlKeyPath(line[:eq])
+			if table != "" {
+				key = table + "." + key
+			}
+			lines[key] = lineNo
+			tomlInlineKeyLines(lines, key, line[eq+1:], lineNo)
+		}
+	}
+	return lines
+}
+
+// tomlInlineKeyLines records the keys of an inline table value, such as
+// { Accept = "text/html" }, nested ones included, on the line it's on
+func tomlInlineKeyLines(lines map[string]int, prefix, value string, lineNo int) {
+	value = strings.TrimSpace(tomlSplit(value, '#')[0])
+	if !strings.HasPrefix(value, "{") || !strings.HasSuffix(value, "}") {
+		return
+	}
+	for _, entry := range tomlSplit(value[1:len(value)-1], ',') {
+		parts := tomlSplit(entry, '=')
+		if len(parts) < 2 {
+			continue
+		}
+		key := prefix + "." + tomlKeyPath(parts[0])
+		lines[key] = lineNo
+		tomlInlineKeyLines(lines, key, strings.Join(parts[1:], "="), lineNo)
+	}
+}
+
+// tomlSplit splits s at every sep outside quotes, arrays and inline tables
+func tomlSplit(s string, sep byte) []string {
+	var parts []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++ // Skip the escaped character
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// tomlKeyPath turns a possibly quoted, dotted TOML key into a dotted path
+func tomlKeyPath(raw string) string {
+	parts := strings.Split(raw, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
+
+// profileKeys lists every key the profile schema accepts. Keys of map
+// settings, such as header names, are free-form and end in ".*".
+var profileKeys = schemaKeys(reflect.TypeOf(crawlProfile{}), "")
+
+// schemaKeys walks the profile structs and collects their tag names
+func schemaKeys(t reflect.Type, prefix string) map[string]bool {
+	keys := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		key := prefix + name
+		keys[key] = true
+
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			for nested := range schemaKeys(field.Type, key+".") {
+				keys[nested] = true
+			}
+		case reflect.Map:
+			keys[key+".*"] = true
+		case reflect.Slice:
+			// Lists of tables share their keys, whatever the index
+			if field.Type.Elem().Kind() == reflect.Struct {
+				for nested := range schemaKeys(field.Type.Elem(), key+".") {
+					keys[nested] = true
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// unknownKeys reports the keys in the file that the schema doesn't know
+func (src profileSource) unknownKeys() profileErrors {
+	var errs profileErrors
+	for _, key := range src.keys {
+		parent, _, _ := cutLast(key, ".")
+		if profileKeys[key] || profileKeys[parent+".*"] {
+			continue
+		}
+		errs = append(errs, src.errorAt(key, "unknown setting"))
+	}
+	return errs
+}
+
+// cutLast splits s around the last sep
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return "", s, false
+}
+
+// validateProfile checks the values the decoder can't: ranges, policy
+// names, patterns, durations and column names
+func validateProfile(p crawlProfile, src profileSource) profileErrors {
+	var errs profileErrors
+	check := func(key string, err error) {
+		if err != nil {
+			errs = append(errs, src.errorAt(key, "%v", err))
+		}
+	}
+
+	if p.URL != nil {
+		check("url", crawler.ValidateURL(*p.URL))
+	}
+
+	// Crawl scope and limits
+	if p.Crawl.Concurrency != nil && *p.Crawl.Concurrency < 1 {
+		errs = append(errs, src.errorAt("crawl.concurrency", "must be at least 1"))
+	}
+	if p.Crawl.MaxPages != nil && *p.Crawl.MaxPages < 1 {
+		errs = append(errs, src.errorAt("crawl.max_pages", "must be at least 1"))
+	}
+	if p.Crawl.Robots != nil {
+		_, err := crawler.ParseRobotsPolicy(*p.Crawl.Robots)
+		check("crawl.robots", err)
+	}
+	// Patterns become space-separated flag values, so they can't hold spaces
+	checkPattern := func(key, pattern string) {
+		if strings.ContainsFunc(pattern, unicode.IsSpace) {
+			check(key, fmt.Errorf("pattern %q contains whitespace, which URLs never do", pattern))
+			return
+		}
+		_, err := regexp.Compile(pattern)
+		check(key, err)
+	}
+	for _, pattern := range p.Crawl.Include {
+		checkPattern("crawl.include", pattern)
+	}
+	for _, pattern := range p.Crawl.Exclude {
+		checkPattern("crawl.exclude", pattern)
+	}
+
+	// Requests
+	for name := range p.Fetch.Headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			errs = append(errs, src.errorAt("fetch.headers."+name, "invalid header name"))
+		}
+	}
+	if p.Fetch.Timeout != nil {
+		timeout, err := time.ParseDuration(*p.Fetch.Timeout)
+		if err == nil && timeout < 0 {
+			err = fmt.Errorf("must not be negative")
+		}
+		check("fetch.timeout", err)
+	}
+	if p.Fetch.RateLimit != nil && *p.Fetch.RateLimit < 0 {
+		errs = append(errs, src.errorAt("fetch.rate_limit", "must not be negative"))
+	}
+	for _, auth := range p.Fetch.Auth {
+		_, err := auth.spec()
+		check("fetch.auth", err)
+	}
+
+	// Login
+	if p.Login.URL != nil {
+		check("login.url", crawler.ValidateURL(*p.Login.URL))
+	}
+	for name, source := range p.Login.SecretFields {
+		_, _, err := crawler.ParseSecretSource(source)
+		check("login.secret_fields."+name, err)
+	}
+	if p.Login.SuccessSelector != nil {
+		_, err := cascadia.ParseGroup(*p.Login.SuccessSelector)
+		check("login.success_selector", err)
+	}
+
+	// URL normalization
+	if p.Normalize.Scheme != nil {
+		_, err := crawler.ParseSchemePolicy(*p.Normalize.Scheme)
+		check("normalize.scheme", err)
+	}
+	if p.Normalize.TrailingSlash != nil {
+		_, err := crawler.ParseSlashPolicy(*p.Normalize.TrailingSlash)
+		check("normalize.trailing_slash", err)
+	}
+	if p.Normalize.IDN != nil {
+		_, err := crawler.ParseIDNPolicy(*p.Normalize.IDN)
+		check("normalize.idn", err)
+	}
+
+	// Custom fields
+	var fields []string
+	for name, selector := range p.Extract {
+		_, err := crawler.NewSelectorExtractor(name, selector)
+		check("extract."+name, err)
+		fields = append(fields, name)
+	}
+
+	// Reports, checked the same way as the matching flags
+	layout := csvFlags{delimiter: ",", sortBy: string(crawler.DefaultSortKey)}
+	if p.Report.Columns != nil {
+		layout.columns = strings.Join(p.Report.Columns, ",")
+		_, err := layout.options(fields)
+		check("report.columns", err)
+		layout.columns = ""
+	}
+	if p.Report.Delimiter != nil {
+		layout.delimiter = *p.Report.Delimiter
+		_, err := layout.options(fields)
+		check("report.delimiter", err)
+		layout.delimiter = ","
+	}
+	if p.Report.Sort != nil {
+		_, err := crawler.ParseSortKey(*p.Report.Sort)
+		check("report.sort", err)
+	}
+	// Each PageRank setting is checked with the defaults for the others
+	pageRankSettings := []struct {
+		key  string
+		set  bool
+		fill func(opts *crawler.PageRankOptions)
+	}{
+		{"report.damping", p.Report.Damping != nil, func(opts *crawler.PageRankOptions) { opts.Damping = deref(p.Report.Damping) }},
+		{"report.pagerank_iterations", p.Report.PageRankIterations != nil, func(opts *crawler.PageRankOptions) { opts.MaxIterations = deref(p.Report.PageRankIterations) }},
+		{"report.pagerank_tolerance", p.Report.PageRankTolerance != nil, func(opts *crawler.PageRankOptions) { opts.Tolerance = deref(p.Report.PageRankTolerance) }},
+		{"report.nofollow", p.Report.Nofollow != nil, func(opts *crawler.PageRankOptions) { opts.Nofollow = crawler.NofollowMode(deref(p.Report.Nofollow)) }},
+	}
+	for _, setting := range pageRankSettings {
+		if setting.set {
+			opts := crawler.PageRankOptions{}.WithDefaults()
+			setting.fill(&opts)
+			check(setting.key, opts.Validate())
+		}
+	}
+	check("report.duplicate_threshold", crawler.ReportOptions{DuplicateThreshold: deref(p.Report.DuplicateThreshold)}.Validate())
+	check("report.max_image_bytes", crawler.ReportOptions{MaxImageBytes: deref(p.Report.MaxImageBytes)}.Validate())
+
+	// SEO rules
+	check("rules.disabled", crawler.RulesOptions{Disabled: p.Rules.Disabled}.Validate())
+	for id, severity := range p.Rules.Severity {
+		check("rules.severity."+id, crawler.RulesOptions{Severities: map[string]string{id: severity}}.Validate())
+	}
+	check("rules", crawler.RulesOptions{Thresholds: crawler.RuleThresholds{
+		TitleMinLength:       deref(p.Rules.TitleMinLength),
+		TitleMaxLength:       deref(p.Rules.TitleMaxLength),
+		DescriptionMinLength: deref(p.Rules.DescriptionMinLength),
+		DescriptionMaxLength: deref(p.Rules.DescriptionMaxLength),
+	}}.Validate())
+
+	return errs
+}
+
+// profileFlag ties a profile setting to the command line flag it fills
+type profileFlag struct {
+	Key   string // Dotted profile key
+	Flag  string // Flag name
+	Value string // Flag value, as it would be typed
+}
+
+// profileFlags lists the profile settings that have a matching flag
+func profileFlags(p crawlProfile) []profileFlag {
+	var settings []profileFlag
+	add := func(key, flagName string, value any) {
+		v := reflect.ValueOf(value)
+		if v.IsNil() {
+			return
+		}
+		var text string
+		if v.Kind() == reflect.Slice {
+			text = strings.Join(value.([]string), ",")
+		} else {
+			text = fmt.Sprint(v.Elem().Interface())
+		}
+		settings = append(settings, profileFlag{Key: key, Flag: flagName, Value: text})
+	}
+
+	// Patterns may contain commas, so they're joined with spaces
+	patterns := func(values []string) *string {
+		if values == nil {
+			return nil
+		}
+		return ptr(strings.Join(values, " "))
+	}
+
+	add("url", "url", p.URL)
+	add("crawl.concurrency", "concurrency", p.Crawl.Concurrency)
+	add("crawl.max_pages", "max-pages", p.Crawl.MaxPages)
+	add("crawl.include", "include", patterns(p.Crawl.Include))
+	add("crawl.exclude", "exclude", patterns(p.Crawl.Exclude))
+	add("crawl.robots", "robots", p.Crawl.Robots)
+	add("crawl.stream", "stream", p.Crawl.Stream)
+	add("fetch.timeout", "timeout", p.Fetch.Timeout)
+	add("fetch.rate_limit", "rate-limit", p.Fetch.RateLimit)
+	add("fetch.user_agent", "user-agent", p.Fetch.UserAgent)
+	add("fetch.cookies_file", "cookies-file", p.Fetch.CookiesFile)
+	add("report.output_dir", "output-dir", p.Report.OutputDir)
+	add("report.columns", "columns", p.Report.Columns)
+	add("report.delimiter", "delimiter", p.Report.Delimiter)
+	add("report.multi_separator", "multi-separator", p.Report.MultiSeparator)
+	add("report.long_format", "long-format", p.Report.LongFormat)
+	add("report.bom", "bom", p.Report.BOM)
+	add("report.sort", "sort", p.Report.Sort)
+	add("report.collapse_canonicals", "collapse-canonicals", p.Report.CollapseCanonicals)
+	add("report.probe_images", "probe-images", p.Report.ProbeImages)
+	add("report.collapse_query", "collapse-query", p.Report.CollapseQuery)
+	add("report.self_loops", "self-loops", p.Report.SelfLoops)
+	add("report.damping", "damping", p.Report.Damping)
+	add("report.pagerank_iterations", "pagerank-iterations", p.Report.PageRankIterations)
+	add("report.pagerank_tolerance", "pagerank-tolerance", p.Report.PageRankTolerance)
+	add("report.nofollow", "nofollow", p.Report.Nofollow)
+	add("report.duplicate_threshold", "duplicate-threshold", p.Report.DuplicateThreshold)
+	add("report.max_image_bytes", "max-image-bytes", p.Report.MaxImageBytes)
+	add("rules.disabled", "disable-rules", p.Rules.Disabled)
+	add("rules.title_min_length", "title-min-length", p.Rules.TitleMinLength)
+	add("rules.title_max_length", "title-max-length", p.Rules.TitleMaxLength)
+	add("rules.description_min_length", "description-min-length", p.Rules.DescriptionMinLength)
+	add("rules.description_max_length", "description-max-length", p.Rules.DescriptionMaxLength)
+	add("login.url", "login-url", p.Login.URL)
+	add("login.success_url", "login-success-url", p.Login.SuccessURL)
+	add("login.success_selector", "login-success-selector", p.Login.SuccessSelector)
+	return settings
+}
+
+// applyProfile loads the profile at path and fills every flag of fs that
+// wasn't set on the command line or through the environment, so flags
+// always override the file. An empty path loads nothing.
+func applyProfile(fs *flag.FlagSet, path string) (crawlProfile, error) {
+	if path == "" {
+		return crawlProfile{}, nil
+	}
+	profile, err := loadProfile(path)
+	if err != nil {
+		return crawlProfile{}, err
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	for _, setting := range profileFlags(profile) {
+		if set[setting.Flag] || fs.Lookup(setting.Flag) == nil {
+			continue
+		}
+		if err := fs.Set(setting.Flag, setting.Value); err != nil {
+			return crawlProfile{}, profileError{File: path, Key: setting.Key, Message: err.Error()}
+		}
+	}
+	return profile, nil
+}
+
+// NormalizeOptions converts the profile's normalization settings, which
+// validateProfile has already checked
+func (p profileNormalize) options() crawler.NormalizeOptions {
+	var opts crawler.NormalizeOptions
+	if p.Scheme != nil {
+		opts.Scheme, _ = crawler.ParseSchemePolicy(*p.Scheme)
+	}
+	if p.TrailingSlash != nil {
+		opts.TrailingSlash, _ = crawler.ParseSlashPolicy(*p.TrailingSlash)
+	}
+	if p.IDN != nil {
+		opts.IDN, _ = crawler.ParseIDNPolicy(*p.IDN)
+	}
+	flags := []struct {
+		value *bool
+		dest  *bool
+	}{
+		{p.KeepDefaultPort, &opts.KeepDefaultPort},
+		{p.KeepDotSegments, &opts.KeepDotSegments},
+		{p.KeepEncoding, &opts.KeepEncoding},
+		{p.KeepQueryOrder, &opts.KeepQueryOrder},
+		{p.DropQuery, &opts.DropQuery},
+		{p.KeepFragment, &opts.KeepFragment},
+	}
+	for _, f := range flags {
+		if f.value != nil {
+			*f.dest = *f.value
+		}
+	}
+	if p.TrackingParams != nil {
+		opts.TrackingParams = append([]string{}, p.TrackingParams...)
+	}
+	return opts
+}
+
+// profileFromOptions describes effective crawl options as a complete
+// profile, with every default filled in. Secret header values are redacted.
+func profileFromOptions(opts crawlOptions) crawlProfile {
+	normalize := opts.Normalize
+	scheme, _ := crawler.ParseSchemePolicy(string(normalize.Scheme))
+	slash, _ := crawler.ParseSlashPolicy(string(normalize.TrailingSlash))
+	idn, _ := crawler.ParseIDNPolicy(string(normalize.IDN))
+	trackingParams := normalize.TrackingParams
+	if trackingParams == nil {
+		trackingParams = crawler.DefaultTrackingParams
+	}
+
+	csv := opts.Reports.CSV.WithDefaults()
+	pageRank := opts.Reports.PageRank.WithDefaults()
+	thresholds := opts.Reports.Rules.Thresholds.WithDefaults()
+	agent := opts.Fetch.UserAgent
+	if agent == "" {
+		agent = crawler.DefaultUserAgent
+	}
+	var cookiesFile *string
+	if opts.Fetch.CookieFile != "" {
+		cookiesFile = &opts.Fetch.CookieFile
+	}
+
+	// Login secrets are printed as where to find them, too
+	var login profileLogin
+	if opts.Fetch.Login.Enabled() {
+		login = profileLogin{
+			URL:          &opts.Fetch.Login.URL,
+			Fields:       opts.Fetch.Login.Fields,
+			SecretFields: opts.Fetch.Login.SecretFields,
+		}
+		if opts.Fetch.Login.SuccessURL != "" {
+			login.SuccessURL = &opts.Fetch.Login.SuccessURL
+		}
+		if opts.Fetch.Login.SuccessSelector != "" {
+			login.SuccessSelector = &opts.Fetch.Login.SuccessSelector
+		}
+	}
+
+	// Credentials are printed as where to find them, never as secrets
+	var auth []profileAuth
+	for _, spec := range opts.Fetch.Auth {
+		auth = append(auth, profileAuth{Host: spec.Host, Type: string(spec.Scheme), Env: spec.Env, File: spec.File})
+	}
+
+	return crawlProfile{
+		URL: &opts.URL,
+		Crawl: profileCrawl{
+			Concurrency: &opts.MaxConcurrency,
+			MaxPages:    &opts.MaxPages,
+			Robots:      ptr(string(opts.Reports.Robots)),
+			Stream:      &opts.Stream,
+			Include:     opts.Include,
+			Exclude:     opts.Exclude,
+		},
+		Fetch: profileFetch{
+			UserAgent:   &agent,
+			Headers:     crawler.RedactHeaders(opts.Fetch.Headers),
+			Timeout:     ptr(opts.Fetch.Timeout.String()),
+			RateLimit:   &opts.Fetch.RateLimit,
+			CookiesFile: cookiesFile,
+			Auth:        auth,
+		},
+		Normalize: profileNormalize{
+			Scheme:          ptr(string(scheme)),
+			TrailingSlash:   ptr(string(slash)),
+			IDN:             ptr(string(idn)),
+			KeepDefaultPort: &normalize.KeepDefaultPort,
+			KeepDotSegments: &normalize.KeepDotSegments,
+			KeepEncoding:    &normalize.KeepEncoding,
+			KeepQueryOrder:  &normalize.KeepQueryOrder,
+			DropQuery:       &normalize.DropQuery,
+			KeepFragment:    &normalize.KeepFragment,
+			TrackingParams:  append([]string{}, trackingParams...),
+		},
+		Rules: profileRules{
+			Disabled:             opts.Reports.Rules.Disabled,
+			Severity:             opts.Reports.Rules.Severities,
+			TitleMinLength:       &thresholds.TitleMinLength,
+			TitleMaxLength:       &thresholds.TitleMaxLength,
+			DescriptionMinLength: &thresholds.DescriptionMinLength,
+			DescriptionMaxLength: &thresholds.DescriptionMaxLength,
+		},
+		Login:   login,
+		Extract: opts.Extract,
+		Report: profileReport{
+			OutputDir:          &opts.Reports.OutputDir,
+			Columns:            append([]string{}, csv.Columns...),
+			Delimiter:          ptr(string(csv.Delimiter)),
+			MultiSeparator:     &csv.MultiSeparator,
+			LongFormat:         &csv.LongFormat,
+			BOM:                &csv.BOM,
+			Sort:               ptr(string(csv.SortBy)),
+			CollapseCanonicals: &opts.Reports.CollapseCanonicals,
+			ProbeImages:        &opts.Reports.ProbeImages,
+			CollapseQuery:      &opts.Reports.CollapseQuery,
+			SelfLoops:          &opts.Reports.KeepSelfLoops,
+			Damping:            &pageRank.Damping,
+			PageRankIterations: &pageRank.MaxIterations,
+			PageRankTolerance:  &pageRank.Tolerance,
+			Nofollow:           ptr(string(pageRank.Nofollow)),
+			DuplicateThreshold: ptr(cmp.Or(opts.Reports.DuplicateThreshold, 0.9)),
+			MaxImageBytes:      ptr(cmp.Or(opts.Reports.MaxImageBytes, crawler.DefaultMaxImageBytes)),
+		},
+	}
+}
+
+// ptr returns a pointer to a copy of v
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// deref returns the value p points to, or the zero value when p is nil
+func deref[T any](p *T) T {
+	var v T
+	if p != nil {
+		v = *p
+	}
+	return v
+}
+
+// writeProfile encodes a profile as YAML or TOML
+func writeProfile(w io.Writer, p crawlProfile, format profileFormat) error {
+	if format == profileTOML {
+		if err := toml.NewEncoder(w).Encode(p); err != nil {
+			return fmt.Errorf("couldn't encode profile: %w", err)
+		}
+		return nil
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(p); err != nil {
+		return fmt.Errorf("couldn't encode profile: %w", err)
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Utkarsh736/linkscout/crawler"
+)
+
+func TestParseProfileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   profileFormat
+		profile  string
+		expected []string
+	}{
+		{
+			name:    "valid YAML",
+			format:  profileYAML,
+			profile: "url: https://example.com\ncrawl:\n  max_pages: 10\nfetch:\n  headers:\n    X-Team: seo\n",
+		},
+		{
+			name:    "valid TOML",
+			format:  profileTOML,
+			profile: "url = \"https://example.com\"\n[crawl]\nmax_pages = 10\n[fetch.headers]\nX-Team = \"seo\"\n",
+		},
+		{
+			name:     "YAML unknown keys",
+			format:   profileYAML,
+			profile:  "crawl:\n  max_page: 10\nreports:\n  bom: true\n",
+			expected: []string{"p:2: crawl.max_page: unknown setting", "p:3: reports: unknown setting", "p:4: reports.bom: unknown setting"},
+		},
+		{
+			name:     "YAML wrong type",
+			format:   profileYAML,
+			profile:  "crawl:\n  concurrency: 2\n  max_pages: lots\n",
+			expected: []string{"p:3: cannot unmarshal !!str `lots` into int"},
+		},
+		{
+			name:   "YAML invalid values",
+			format: profileYAML,
+			profile: "url: example.com\ncrawl:\n  concurrency: 0\n  robots: maybe\n  exclude: ['(']\n" +
+				"fetch:\n  timeout: soon\nnormalize:\n  scheme: ftp\nreport:\n  sort: size\n",
+			expected: []string{
+				"p:1: url: URL must be absolute",
+				"p:3: crawl.concurrency: must be at least 1",
+				"p:4: crawl.robots: unknown robots policy",
+				"p:5: crawl.exclude: error parsing regexp",
+				"p:7: fetch.timeout: time: invalid duration",
+				"p:9: normalize.scheme: unknown scheme policy",
+				"p:11: report.sort: unknown sort key",
+			},
+		},
+		{
+			name:     "TOML unknown key and invalid value",
+			format:   profileTOML,
+			profile:  "[report]\ncolumns = [\"page_url\", \"nope\"]\n\n[normalize]\nidn = \"ascii\"\nextra = 1\n",
+			expected: []string{"p:2: report.columns: unknown column \"nope\"", "p:5: normalize.idn: unknown IDN policy", "p:6: normalize.extra: unknown setting"},
+		},
+		{
+			name:     "TOML inline table",
+			format:   profileTOML,
+			profile:  "normalize = { idn = \"ascii\", extra = 1 }\nfetch = { headers = { \"X-Tag\" = \"a,b\" }, retries = 2 } # comment\n",
+			expected: []string{"p:1: normalize.extra: unknown setting", "p:1: normalize.idn: unknown IDN policy", "p:2: fetch.retries: unknown setting"},
+		},
+		{
+			name:     "YAML report analysis values",
+			format:   profileYAML,
+			profile:  "report:\n  damping: 2\n  nofollow: sometimes\n  duplicate_threshold: -1\n",
+			expected: []string{"p:2: report.damping: damping factor must be between 0 and 1", "p:3: report.nofollow: unknown nofollow mode", "p:4: report.duplicate_threshold: duplicate threshold must be between 0 and 1"},
+		},
+		{
+			name:     "YAML zero damping factor",
+			format:   profileYAML,
+			profile:  "report:\n  damping: 0\n",
+			expected: []string{"p:2: report.damping: damping factor must be between 0 and 1, exclusive, got 0"},
+		},
+		{
+			name:     "YAML SEO rules",
+			format:   profileYAML,
+			profile:  "rules:\n  disabled: [nope]\n  severity:\n    title-duplicate: fatal\n",
+			expected: []string{`p:2: rules.disabled: unknown SEO rule "nope"`, "p:4: rules.severity.title-duplicate: rule title-duplicate"},
+		},
+		{
+			name:     "TOML wrong type",
+			format:   profileTOML,
+			profile:  "[crawl]\nconcurrency = 2\nmax_pages = \"lots\"\n",
+			expected: []string{"p:3: crawl.max_pages: incompatible types"},
+		},
+		{
+			name:     "TOML syntax error",
+			format:   profileTOML,
+			profile:  "[crawl]\nmax_pages = \n",
+			expected: []string{"p:2: crawl.max_pages: expected value"},
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseProfile([]byte(tc.profile), "p", tc.format)
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Fatalf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Test %v - '%s' FAIL: expected errors, got none", i, tc.name)
+			}
+
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(tc.expected) {
+				t.Fatalf("Test %v - %s FAIL: expected %d errors, got:\n%v", i, tc.name, len(tc.expected), err)
+			}
+			for j, expected := range tc.expected {
+				if !strings.HasPrefix(lines[j], expected) {
+					t.Errorf("Test %v - %s FAIL: expected error %d to start with %q, got %q", i, tc.name, j, expected, lines[j])
+				}
+			}
+		})
+	}
+}
+
+func TestProfilePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "staging.yaml")
+	profile := `url: https://staging.example.com
+crawl:
+  concurrency: 3
+  max_pages: 500
+  include: ['^https://staging\.example\.com/(docs|api){1,2}/']
+  exclude: ['/logout']
+fetch:
+  user_agent: StagingBot/2.0
+  timeout: 5s
+normalize:
+  trailing_slash: keep
+report:
+  sort: depth
+`
+	if err := os.WriteFile(path, []byte(profile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The flag beats the environment, which beats the profile
+	t.Setenv("LINKSCOUT_MAX_PAGES", "50")
+	t.Setenv("LINKSCOUT_CONCURRENCY", "4")
+	t.Setenv("LINKSCOUT_EXCLUDE", "/print /archive/")
+	var stderr bytes.Buffer
+	opts, err := parseCrawlArgs([]string{"--config", path, "--concurrency", "8"}, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.URL != "https://staging.example.com" {
+		t.Errorf("expected the profile URL, got %q", opts.URL)
+	}
+	if opts.MaxConcurrency != 8 {
+		t.Errorf("expected the flag to win, got concurrency %d", opts.MaxConcurrency)
+	}
+	if opts.MaxPages != 50 {
+		t.Errorf("expected the environment to beat the profile, got max pages %d", opts.MaxPages)
+	}
+	if opts.Fetch.Timeout != 5*time.Second || opts.Fetch.UserAgent != "StagingBot/2.0" {
+		t.Errorf("expected the profile fetch settings, got %+v", opts.Fetch)
+	}
+	if expected := []string{"/print", "/archive/"}; !reflect.DeepEqual(opts.Exclude, expected) {
+		t.Errorf("expected the environment's exclude patterns, got %q", opts.Exclude)
+	}
+	if expected := []string{`^https://staging\.example\.com/(docs|api){1,2}/`}; !reflect.DeepEqual(opts.Include, expected) || opts.Normalize.TrailingSlash != crawler.SlashKeep || opts.Reports.CSV.SortBy != crawler.SortByDepth {
+		t.Errorf("expected the profile scope, normalization and sort, got %v %+v %q", opts.Include, opts.Normalize, opts.Reports.CSV.SortBy)
+	}
+
+	// A positional URL beats the profile too
+	opts, err = parseCrawlArgs([]string{"https://example.com", "--config", path}, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.URL != "https://example.com" {
+		t.Errorf("expected the argument URL, got %q", opts.URL)
+	}
+
+	// Repeated --include flags replace the profile's patterns
+	opts, err = parseCrawlArgs([]string{"--config", path, "--include", "^https://staging\\.example\\.com/blog/", "--include", "/news/"}, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{`^https://staging\.example\.com/blog/`, "/news/"}; !reflect.DeepEqual(opts.Include, expected) {
+		t.Errorf("expected the flag's include patterns, got %q", opts.Include)
+	}
+}
+
+func TestConfigPrintRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, format := range []profileFormat{profileYAML, profileTOML} {
+		t.Run(string(format), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := []string{"print", "--format", string(format), "https://example.com", "--max-pages", "42", "--delimiter", `\t`}
+			if code := runConfigCommand(args, &stdout, &stderr); code != exitOK {
+				t.Fatalf("config print failed with exit code %d: %s", code, stderr.String())
+			}
+
+			// The printed configuration loads back as the same settings
+			path := filepath.Join(dir, "printed."+string(format))
+			if err := os.WriteFile(path, stdout.Bytes(), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			printed, err := parseCrawlArgs([]string{"--config", path}, &stderr)
+			if err != nil {
+				t.Fatalf("couldn't load printed profile: %v\n%s", err, stdout.String())
+			}
+			if printed.URL != "https://example.com" || printed.MaxPages != 42 || printed.Reports.CSV.Delimiter != '\t' {
+				t.Errorf("unexpected settings from printed profile: %+v", printed)
+			}
+			if len(printed.Normalize.TrackingParams) != len(crawler.DefaultTrackingParams) {
+				t.Errorf("expected the default tracking parameters, got %v", printed.Normalize.TrackingParams)
+			}
+		})
+	}
+}
+
+func TestConfigPrintRedactsSecrets(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"print", "https://example.com",
+		"--header", "Authorization: Bearer abc123", "--header", "X-Team: seo",
+		"--auth", "example.com=basic:env:STAGING_CREDS"}
+	if code := runConfigCommand(args, &stdout, &stderr); code != exitOK {
+		t.Fatalf("config print failed with exit code %d: %s", code, stderr.String())
+	}
+
+	printed := stdout.String()
+	if strings.Contains(printed, "abc123") {
+		t.Errorf("expected the Authorization header to be redacted:\n%s", printed)
+	}
+	for _, expected := range []string{"X-Team: seo", "env: STAGING_CREDS", "type: basic"} {
+		if !strings.Contains(printed, expected) {
+			t.Errorf("expected %q in:\n%s", expected, printed)
+		}
+	}
+}